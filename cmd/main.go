@@ -2,22 +2,46 @@ package main
 
 import (
 	"context"
+	"flag"
 
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/joho/godotenv"
 	"github.com/sashabaranov/go-openai"
+	"github.com/soralabs/hana/internal/agents"
+	"github.com/soralabs/hana/internal/approval"
+	"github.com/soralabs/hana/internal/managers/guardrails"
+	sora_manager "github.com/soralabs/hana/internal/managers/sora"
+	"github.com/soralabs/hana/internal/marketdata"
+	"github.com/soralabs/hana/internal/tipping"
 	"github.com/soralabs/hana/internal/twitter"
 	"github.com/soralabs/solana-toolkit/go/toolkit"
 	"github.com/soralabs/zen/llm"
 	"github.com/soralabs/zen/logger"
+	"github.com/soralabs/zen/options"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// service is what main runs, a single agent or the runner of several
+type service interface {
+	Start() error
+	Stop() error
+	ReloadPersona() error
+}
+
 func main() {
+	personaPath := flag.String("persona", "", "persona file to load, defaults to $PERSONA_FILE or personas/hana.yaml")
+	agentsPath := flag.String("agents", "", "agents file to run several agents from, defaults to $AGENTS_FILE")
+	flag.Parse()
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Fatal("Error loading .env file")
@@ -34,7 +58,13 @@ func main() {
 		log.Fatalf("Failed to create logger: %v", err)
 	}
 
-	// Create context
+	// Interrupts are caught before anything is created, so a signal during startup aborts it
+	// instead of killing the process halfway through
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	// The root context stays alive until zen has stopped so that in-flight
+	// LLM calls and tweet posts can finish instead of being canceled mid-way
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -74,41 +104,247 @@ func main() {
 		log.Fatalf("Failed to create solana toolkit: %v", err)
 	}
 
-	// Create Twitter instance with options
-	k, err := twitter.New(
-		twitter.WithContext(ctx),
-		twitter.WithLogger(log.NewSubLogger("zen", &logger.SubLoggerOpts{})),
+	// The toolkit keeps its RPC client to itself, on-chain metrics need their own
+	solanaRPC := rpc.New(os.Getenv("SOLANA_RPC_URL"))
+
+	// Load the guardrails policy, falling back to the default one
+	guardrailsPolicy := &guardrails.DefaultPolicy
+	if path := os.Getenv("GUARDRAILS_POLICY_PATH"); path != "" {
+		guardrailsPolicy, err = guardrails.LoadPolicy(path)
+		if err != nil {
+			log.Fatalf("Failed to load guardrails policy: %v", err)
+		}
+	}
+
+	// Tickers of major coins can be talked about without tripping the shill rules
+	allowedCashtags := guardrails.DefaultAllowedCashtags
+	if value := os.Getenv("SHILL_ALLOWED_CASHTAGS"); value != "" {
+		allowedCashtags, err = guardrails.ParseCashtags(value)
+		if err != nil {
+			log.Fatalf("Failed to parse allowed cashtags: %v", err)
+		}
+	}
+
+	// Track ecosystem tokens alongside Sora
+	tokenWatchlist := sora_manager.DefaultWatchlist
+	if value := os.Getenv("TOKEN_WATCHLIST"); value != "" {
+		ecosystemTokens, err := sora_manager.ParseWatchlist(value)
+		if err != nil {
+			log.Fatalf("Failed to parse token watchlist: %v", err)
+		}
+		tokenWatchlist = append(tokenWatchlist, ecosystemTokens...)
+	}
+
+	// Label known wallets in whale transfers
+	whaleConfig := sora_manager.DefaultWhaleConfig
+	if value := os.Getenv("KNOWN_WALLETS"); value != "" {
+		whaleConfig.KnownWallets, err = sora_manager.ParseKnownWallets(value)
+		if err != nil {
+			log.Fatalf("Failed to parse known wallets: %v", err)
+		}
+	}
+	if value := os.Getenv("WHALE_THRESHOLD_USD"); value != "" {
+		whaleConfig.ThresholdUsd, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.Fatalf("Failed to parse whale threshold: %v", err)
+		}
+	}
+
+	// Several agents run from an agents file, picked by the -agents flag, then AGENTS_FILE
+	if *agentsPath == "" {
+		*agentsPath = os.Getenv("AGENTS_FILE")
+	}
+
+	// The persona file is picked by the -persona flag, then PERSONA_FILE
+	if *personaPath == "" {
+		*personaPath = os.Getenv("PERSONA_FILE")
+	}
+	if *personaPath == "" {
+		*personaPath = "personas/hana.yaml"
+	}
+
+	// Tipping is opt-in and only simulates transactions unless TIPPING_DRY_RUN is false
+	var tipper *tipping.Tipper
+	if os.Getenv("TIPPING_ENABLED") == "true" {
+		signer, err := solana.PrivateKeyFromBase58(os.Getenv("TIPPING_PRIVATE_KEY"))
+		if err != nil {
+			log.Fatalf("Failed to parse tipping private key: %v", err)
+		}
+
+		tippingConfig := tipping.DefaultConfig
+		tippingConfig.DryRun = os.Getenv("TIPPING_DRY_RUN") != "false"
+		tippingConfig.KillSwitchFile = os.Getenv("TIPPING_KILL_SWITCH_FILE")
+		if value := os.Getenv("TIPPING_AMOUNT"); value != "" {
+			tippingConfig.TipAmount, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				log.Fatalf("Failed to parse tip amount: %v", err)
+			}
+		}
+		if value := os.Getenv("TIPPING_DAILY_BUDGET"); value != "" {
+			tippingConfig.DailyBudget, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				log.Fatalf("Failed to parse tipping daily budget: %v", err)
+			}
+		}
+
+		tipper, err = tipping.New(
+			tipping.WithLogger(log.NewSubLogger("tipping", &logger.SubLoggerOpts{})),
+			tipping.WithDatabase(db),
+			tipping.WithLLM(llmClient),
+			tipping.WithSolanaRPC(solanaRPC),
+			tipping.WithSigner(signer),
+			tipping.WithAllowedSender(os.Getenv("TIPPING_WALLET")),
+			tipping.WithMint(sora_manager.SoraMintAddress),
+			tipping.WithConfig(tippingConfig),
+		)
+		if err != nil {
+			log.Fatalf("Failed to create tipper: %v", err)
+		}
+	}
+
+	// Responses wait for approval through a local admin API when APPROVAL_MODE is true
+	var approvalQueue *approval.Queue
+	if os.Getenv("APPROVAL_MODE") == "true" {
+		if *agentsPath != "" {
+			log.Fatalf("Approval mode only supports a single agent, unset AGENTS_FILE to use it")
+		}
+
+		approvalOpts := []options.Option[approval.Queue]{
+			approval.WithContext(ctx),
+			approval.WithLogger(log.NewSubLogger("approval", &logger.SubLoggerOpts{})),
+			approval.WithDatabase(db),
+			approval.WithAdminToken(os.Getenv("APPROVAL_ADMIN_TOKEN")),
+		}
+		if value := os.Getenv("APPROVAL_LISTEN_ADDR"); value != "" {
+			approvalOpts = append(approvalOpts, approval.WithListenAddr(value))
+		}
+		if value := os.Getenv("APPROVAL_EXPIRE_AFTER"); value != "" {
+			expireAfter, err := time.ParseDuration(value)
+			if err != nil {
+				log.Fatalf("Failed to parse approval expiry: %v", err)
+			}
+			approvalOpts = append(approvalOpts, approval.WithExpireAfter(expireAfter))
+		}
+
+		approvalQueue, err = approval.New(approvalOpts...)
+		if err != nil {
+			log.Fatalf("Failed to create approval queue: %v", err)
+		}
+	}
+
+	// Every agent shares the database, LLM client, Solana clients and tipper.
+	// The tipper has one wallet, so its daily budget and per-actor limits count the tips of all agents.
+	sharedOpts := []options.Option[twitter.Twitter]{
 		twitter.WithDatabase(db),
 		twitter.WithLLM(llmClient),
 		twitter.WithSolanaToolkit(solanaToolkit),
-		twitter.WithTwitterMonitorInterval(
-			4*time.Hour,  // min interval
-			12*time.Hour, // max interval
-		),
-		twitter.WithTweetInterval(
-			12*time.Hour, // min interval
-			24*time.Hour, // max interval
-		),
-		twitter.WithTwitterCredentials(
-			os.Getenv("TWITTER_CT0"),
-			os.Getenv("TWITTER_AUTH_TOKEN"),
-			os.Getenv("TWITTER_USER"),
-		),
-	)
-	if err != nil {
-		log.Fatalf("Failed to create zen: %v", err)
+		twitter.WithSolanaRPC(solanaRPC),
+		twitter.WithGuardrailsPolicy(guardrailsPolicy),
+		twitter.WithAllowedCashtags(allowedCashtags...),
+		twitter.WithTokenWatchlist(tokenWatchlist...),
+		twitter.WithWhaleConfig(whaleConfig),
+		twitter.WithTipper(tipper),
 	}
 
-	// Start zen
-	if err := k.Start(); err != nil {
+	var zen service
+	if *agentsPath != "" {
+		if os.Getenv("DRY_RUN") == "true" {
+			log.Fatalf("DRY_RUN doesn't apply to an agents file, set dry_run on each agent instead")
+		}
+
+		agentsConfig, err := agents.LoadConfig(*agentsPath)
+		if err != nil {
+			log.Fatalf("Failed to load agents: %v", err)
+		}
+
+		// one chain so every agent shares the rate limits and response caches
+		marketData, err := marketdata.NewDefaultChain()
+		if err != nil {
+			log.Fatalf("Failed to create market data provider: %v", err)
+		}
+
+		zen, err = agents.New(
+			agents.WithContext(ctx),
+			agents.WithLogger(log.NewSubLogger("zen", &logger.SubLoggerOpts{})),
+			agents.WithConfig(agentsConfig),
+			agents.WithAgentOptions(sharedOpts...),
+			agents.WithAgentOptions(twitter.WithMarketDataProvider(marketData)),
+		)
+		if err != nil {
+			log.Fatalf("Failed to create agents: %v", err)
+		}
+	} else {
+		// Create Twitter instance with options
+		twitterOpts := append(sharedOpts,
+			twitter.WithContext(ctx),
+			twitter.WithLogger(log.NewSubLogger("zen", &logger.SubLoggerOpts{})),
+			twitter.WithPersonaFile(*personaPath),
+			twitter.WithApprovalQueue(approvalQueue),
+			twitter.WithTwitterMonitorInterval(
+				4*time.Hour,  // min interval
+				12*time.Hour, // max interval
+			),
+			twitter.WithTweetInterval(
+				12*time.Hour, // min interval
+				24*time.Hour, // max interval
+			),
+			twitter.WithTwitterCredentials(
+				os.Getenv("TWITTER_CT0"),
+				os.Getenv("TWITTER_AUTH_TOKEN"),
+				os.Getenv("TWITTER_USER"),
+			),
+		)
+
+		// Dry run mode records would-be tweets instead of posting them
+		if os.Getenv("DRY_RUN") == "true" {
+			dryRunPath := os.Getenv("DRY_RUN_PATH")
+			if dryRunPath == "" {
+				dryRunPath = "dry_run.jsonl"
+			}
+			twitterOpts = append(twitterOpts, twitter.WithDryRun(dryRunPath))
+		}
+
+		zen, err = twitter.New(twitterOpts...)
+		if err != nil {
+			log.Fatalf("Failed to create zen: %v", err)
+		}
+	}
+
+	if signalCtx.Err() != nil {
+		log.Infof("Shutdown signal received during startup, not starting zen")
+		return
+	}
+
+	// Start zen, stopping whatever started before the failure
+	if err := zen.Start(); err != nil {
+		if stopErr := zen.Stop(); stopErr != nil {
+			log.Errorf("Error stopping zen: %v", stopErr)
+		}
 		log.Fatalf("Failed to start zen: %v", err)
 	}
 
-	// Wait for interrupt signal
-	<-ctx.Done()
+	// SIGHUP reloads the persona file without restarting anything
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	defer signal.Stop(reloadSignals)
+	go func() {
+		for range reloadSignals {
+			log.Infof("Reload signal received, reloading persona")
+			if err := zen.ReloadPersona(); err != nil {
+				log.Errorf("Failed to reload persona, keeping the current one: %v", err)
+			}
+		}
+	}()
+
+	// Wait for interrupt signal, which may have arrived during startup already
+	<-signalCtx.Done()
+	stopSignals() // a second signal kills the process immediately
+	log.Infof("Shutdown signal received, stopping zen")
 
 	// Stop zen gracefully
-	if err := k.Stop(); err != nil {
+	if err := zen.Stop(); err != nil {
 		log.Errorf("Error stopping zen: %v", err)
 	}
+
+	cancel()
 }
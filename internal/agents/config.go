@@ -0,0 +1,186 @@
+package agents
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/soralabs/hana/internal/twitter"
+	"github.com/soralabs/zen/options"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// DefaultMonitorInterval is how often an agent checks its mentions unless its config says otherwise
+	DefaultMonitorInterval = IntervalConfig{Min: 4 * time.Hour, Max: 12 * time.Hour}
+	// DefaultTweetInterval is how often an agent tweets unless its config says otherwise
+	DefaultTweetInterval = IntervalConfig{Min: 12 * time.Hour, Max: 24 * time.Hour}
+)
+
+// nameRegex matches agent names and schemas, names double as schemas
+var nameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// LoadConfig reads and validates an agents file.
+// ${VAR} references are replaced with environment variables first, so credentials can stay out of the file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agents file: %w", err)
+	}
+
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(os.ExpandEnv(string(data)))))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse agents file: %w", err)
+	}
+
+	for i := range config.Agents {
+		config.Agents[i].applyDefaults()
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid agents file %s: %w", path, err)
+	}
+
+	return &config, nil
+}
+
+// Validate checks every agent and that no two agents share an account, an assistant or tables.
+// Every problem is reported, not just the first one.
+func (c *Config) Validate() error {
+	if len(c.Agents) == 0 {
+		return fmt.Errorf("at least one agent is required")
+	}
+
+	var errs []error
+	names := make(map[string]bool)
+	assistants := make(map[string]string)
+	schemas := make(map[string]string)
+	users := make(map[string]string)
+	for _, agent := range c.Agents {
+		if err := agent.Validate(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if names[agent.Name] {
+			errs = append(errs, fmt.Errorf("agent %s is defined more than once", agent.Name))
+			continue
+		}
+		names[agent.Name] = true
+
+		if other, exists := assistants[agent.assistant()]; exists {
+			errs = append(errs, fmt.Errorf("agents %s and %s share the assistant %s", other, agent.Name, agent.assistant()))
+		}
+		assistants[agent.assistant()] = agent.Name
+
+		if other, exists := schemas[agent.schema()]; exists {
+			errs = append(errs, fmt.Errorf("agents %s and %s share the schema %q", other, agent.Name, agent.schema()))
+		}
+		schemas[agent.schema()] = agent.Name
+
+		if other, exists := users[agent.Twitter.User]; exists {
+			errs = append(errs, fmt.Errorf("agents %s and %s share the Twitter account %s", other, agent.Name, agent.Twitter.User))
+		}
+		users[agent.Twitter.User] = agent.Name
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate checks that the agent has everything it needs to run
+func (c *AgentConfig) Validate() error {
+	if !nameRegex.MatchString(c.Name) {
+		return fmt.Errorf("name %q must be lowercase letters, digits and underscores", c.Name)
+	}
+
+	var errs []error
+	if schema := c.schema(); schema != "" && !nameRegex.MatchString(schema) {
+		errs = append(errs, fmt.Errorf("schema %q must be lowercase letters, digits and underscores", schema))
+	}
+	if c.Persona == "" {
+		errs = append(errs, fmt.Errorf("persona is required"))
+	}
+	if c.Twitter.User == "" {
+		errs = append(errs, fmt.Errorf("twitter user is required"))
+	}
+	if c.Twitter.CT0 == "" || c.Twitter.AuthToken == "" {
+		errs = append(errs, fmt.Errorf("twitter ct0 and auth token are required"))
+	}
+	if c.MonitorInterval.Min > c.MonitorInterval.Max {
+		errs = append(errs, fmt.Errorf("minimum monitor interval cannot be greater than maximum interval"))
+	}
+	if c.TweetInterval.Min > c.TweetInterval.Max {
+		errs = append(errs, fmt.Errorf("minimum tweet interval cannot be greater than maximum interval"))
+	}
+	for _, managerID := range c.Managers {
+		if !slices.Contains(twitter.OptionalManagers, managerID) {
+			errs = append(errs, fmt.Errorf("unknown optional manager %s", managerID))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("agent %s: %w", c.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// applyDefaults fills in the intervals the config leaves out
+func (c *AgentConfig) applyDefaults() {
+	if c.MonitorInterval == (IntervalConfig{}) {
+		c.MonitorInterval = DefaultMonitorInterval
+	}
+	if c.TweetInterval == (IntervalConfig{}) {
+		c.TweetInterval = DefaultTweetInterval
+	}
+}
+
+// assistant returns the name the agent's assistant ID is derived from
+func (c *AgentConfig) assistant() string {
+	if c.Assistant != "" {
+		return c.Assistant
+	}
+	return c.Name
+}
+
+// schema returns the schema of the agent's tables
+func (c *AgentConfig) schema() string {
+	if c.Schema != nil {
+		return *c.Schema
+	}
+	return c.Name
+}
+
+// options returns the options that set up a Twitter agent as configured
+func (c *AgentConfig) options() []options.Option[twitter.Twitter] {
+	opts := []options.Option[twitter.Twitter]{
+		twitter.WithAssistant(c.assistant()),
+		twitter.WithSchema(c.schema()),
+		twitter.WithPersonaFile(c.Persona),
+		twitter.WithTwitterMonitorInterval(c.MonitorInterval.Min, c.MonitorInterval.Max),
+		twitter.WithTweetInterval(c.TweetInterval.Min, c.TweetInterval.Max),
+		twitter.WithTwitterCredentials(c.Twitter.CT0, c.Twitter.AuthToken, c.Twitter.User),
+	}
+	if c.Managers != nil {
+		opts = append(opts, twitter.WithManagers(c.Managers...))
+	}
+	if c.DryRun != "" {
+		opts = append(opts, twitter.WithDryRun(c.DryRun))
+	}
+	return opts
+}
+
+// agent returns the config of the named agent
+func (c *Config) agent(name string) (AgentConfig, bool) {
+	for _, agent := range c.Agents {
+		if agent.Name == name {
+			return agent, true
+		}
+	}
+	return AgentConfig{}, false
+}
@@ -0,0 +1,57 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soralabs/hana/internal/twitter"
+	"github.com/soralabs/zen/logger"
+	"github.com/soralabs/zen/options"
+)
+
+// ValidateRequiredFields checks the dependencies and config of the runner
+func (r *Runner) ValidateRequiredFields() error {
+	if r.ctx == nil {
+		return fmt.Errorf("context is required")
+	}
+	if r.logger == nil {
+		return fmt.Errorf("logger is required")
+	}
+	if r.config == nil {
+		return fmt.Errorf("agents config is required")
+	}
+	return r.config.Validate()
+}
+
+// WithContext sets the context, every agent gets its own context derived from it
+func WithContext(ctx context.Context) options.Option[Runner] {
+	return func(r *Runner) error {
+		r.ctx = ctx
+		return nil
+	}
+}
+
+// WithLogger sets the logger, every agent logs to a sub logger named after it
+func WithLogger(logger *logger.Logger) options.Option[Runner] {
+	return func(r *Runner) error {
+		r.logger = logger
+		return nil
+	}
+}
+
+// WithConfig sets the agents to run
+func WithConfig(config *Config) options.Option[Runner] {
+	return func(r *Runner) error {
+		r.config = config
+		return nil
+	}
+}
+
+// WithAgentOptions sets the options every agent is created with, like the shared database and LLM client.
+// The context, logger and everything in an agent's config are set by the runner.
+func WithAgentOptions(opts ...options.Option[twitter.Twitter]) options.Option[Runner] {
+	return func(r *Runner) error {
+		r.agentOpts = append(r.agentOpts, opts...)
+		return nil
+	}
+}
@@ -0,0 +1,178 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/soralabs/hana/internal/twitter"
+	"github.com/soralabs/zen/logger"
+	"github.com/soralabs/zen/options"
+)
+
+// New creates a runner for the agents of the config, no agent is started yet
+func New(opts ...options.Option[Runner]) (*Runner, error) {
+	r := &Runner{
+		newAgent: func(opts ...options.Option[twitter.Twitter]) (agent, error) {
+			return twitter.New(opts...)
+		},
+		running: make(map[string]*runningAgent),
+	}
+
+	if err := options.ApplyOptions(r, opts...); err != nil {
+		return nil, fmt.Errorf("failed to apply options: %w", err)
+	}
+
+	return r, nil
+}
+
+// Start starts every agent that isn't disabled.
+// An agent that fails to start is logged and doesn't keep the others from starting,
+// an error is only returned when no agent is running.
+func (r *Runner) Start() error {
+	var errs []error
+	for _, config := range r.config.Agents {
+		if config.Disabled {
+			continue
+		}
+		if err := r.StartAgent(config.Name); err != nil {
+			r.logger.Errorf("Failed to start agent %s: %v", config.Name, err)
+			errs = append(errs, err)
+		}
+	}
+
+	if len(r.Running()) == 0 {
+		return fmt.Errorf("no agent is running: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Stop stops every running agent at the same time and waits for all of them
+func (r *Runner) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var (
+		wg     sync.WaitGroup
+		errsMu sync.Mutex
+		errs   []error
+	)
+	for name, running := range r.running {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.stop(name, running); err != nil {
+				errsMu.Lock()
+				errs = append(errs, err)
+				errsMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	clear(r.running)
+	return errors.Join(errs...)
+}
+
+// StartAgent creates and starts the named agent, disabled agents included
+func (r *Runner) StartAgent(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.running[name]; exists {
+		return fmt.Errorf("agent %s is already running", name)
+	}
+	config, exists := r.config.agent(name)
+	if !exists {
+		return fmt.Errorf("unknown agent %s", name)
+	}
+
+	// the agent's context is canceled with the root context, or once the runner has stopped the agent
+	ctx, cancel := context.WithCancel(r.ctx)
+	opts := slices.Clone(r.agentOpts)
+	opts = append(opts,
+		twitter.WithContext(ctx),
+		twitter.WithLogger(r.logger.NewSubLogger(name, &logger.SubLoggerOpts{})),
+	)
+	opts = append(opts, config.options()...)
+
+	agent, err := r.newAgent(opts...)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to create agent %s: %w", name, err)
+	}
+
+	if err := agent.Start(); err != nil {
+		stopErr := agent.Stop()
+		cancel()
+		return errors.Join(fmt.Errorf("failed to start agent %s: %w", name, err), stopErr)
+	}
+
+	r.running[name] = &runningAgent{
+		agent:  agent,
+		cancel: cancel,
+	}
+	r.logger.WithFields(map[string]interface{}{
+		"agent":     name,
+		"assistant": config.assistant(),
+		"schema":    config.schema(),
+	}).Infof("Started agent as @%s", config.Twitter.User)
+
+	return nil
+}
+
+// StopAgent stops the named agent, the other agents keep running
+func (r *Runner) StopAgent(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	running, exists := r.running[name]
+	if !exists {
+		return fmt.Errorf("agent %s is not running", name)
+	}
+	delete(r.running, name)
+
+	return r.stop(name, running)
+}
+
+// ReloadPersona reloads the persona file of every running agent.
+// An agent whose file is invalid keeps its current persona.
+func (r *Runner) ReloadPersona() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for name, running := range r.running {
+		if err := running.agent.ReloadPersona(); err != nil {
+			errs = append(errs, fmt.Errorf("agent %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Running returns the names of the running agents
+func (r *Runner) Running() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.running))
+	for name := range r.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// stop stops an agent and cancels its context
+func (r *Runner) stop(name string, running *runningAgent) error {
+	defer running.cancel()
+
+	if err := running.agent.Stop(); err != nil {
+		return fmt.Errorf("failed to stop agent %s: %w", name, err)
+	}
+	r.logger.Infof("Stopped agent %s", name)
+	return nil
+}
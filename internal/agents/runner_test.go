@@ -0,0 +1,123 @@
+package agents
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/soralabs/hana/internal/testutil"
+	"github.com/soralabs/hana/internal/twitter"
+	"github.com/soralabs/zen/options"
+)
+
+// fakeAgent records whether it runs
+type fakeAgent struct {
+	mu      sync.Mutex
+	running bool
+	stopped bool
+}
+
+func (a *fakeAgent) Start() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.running = true
+	return nil
+}
+
+func (a *fakeAgent) Stop() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.running = false
+	a.stopped = true
+	return nil
+}
+
+func (a *fakeAgent) ReloadPersona() error { return nil }
+
+// newTestRunner creates a runner for the named agents whose agents are fakes, in creation order
+func newTestRunner(t *testing.T, names ...string) (*Runner, *[]*fakeAgent) {
+	t.Helper()
+
+	config := &Config{}
+	for _, name := range names {
+		agent := AgentConfig{
+			Name:    name,
+			Persona: "personas/" + name + ".yaml",
+			Twitter: TwitterConfig{User: name, CT0: "ct0", AuthToken: "token"},
+		}
+		agent.applyDefaults()
+		config.Agents = append(config.Agents, agent)
+	}
+
+	r, err := New(
+		WithContext(context.Background()),
+		WithLogger(testutil.NewLogger(t)),
+		WithConfig(config),
+	)
+	if err != nil {
+		t.Fatalf("failed to create runner: %v", err)
+	}
+
+	var created []*fakeAgent
+	r.newAgent = func(opts ...options.Option[twitter.Twitter]) (agent, error) {
+		agent := &fakeAgent{}
+		created = append(created, agent)
+		return agent, nil
+	}
+	return r, &created
+}
+
+func TestStartAgent(t *testing.T) {
+	r, created := newTestRunner(t, "hana", "kai")
+
+	if err := r.StartAgent("kai"); err != nil {
+		t.Fatalf("StartAgent: %v", err)
+	}
+	if running := r.Running(); !slices.Equal(running, []string{"kai"}) {
+		t.Errorf("got running agents %v, want only kai", running)
+	}
+	if len(*created) != 1 || !(*created)[0].running {
+		t.Fatalf("got %d created agents, want kai created and started", len(*created))
+	}
+
+	if err := r.StartAgent("kai"); err == nil {
+		t.Error("started kai twice")
+	}
+	if err := r.StartAgent("mika"); err == nil {
+		t.Error("started an unknown agent")
+	}
+	if len(*created) != 1 {
+		t.Errorf("got %d created agents after the failed starts, want 1", len(*created))
+	}
+}
+
+func TestStopAgent(t *testing.T) {
+	r, created := newTestRunner(t, "hana", "kai")
+	if err := r.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	hana, kai := (*created)[0], (*created)[1]
+
+	if err := r.StopAgent("kai"); err != nil {
+		t.Fatalf("StopAgent: %v", err)
+	}
+	if !kai.stopped || !hana.running {
+		t.Errorf("got kai stopped %v and hana running %v, want only kai stopped", kai.stopped, hana.running)
+	}
+	if running := r.Running(); !slices.Equal(running, []string{"hana"}) {
+		t.Errorf("got running agents %v, want only hana", running)
+	}
+
+	if err := r.StopAgent("kai"); err == nil {
+		t.Error("stopped kai twice")
+	}
+
+	// a stopped agent can be started again
+	if err := r.StartAgent("kai"); err != nil {
+		t.Fatalf("StartAgent after StopAgent: %v", err)
+	}
+	if running := r.Running(); !slices.Equal(running, []string{"hana", "kai"}) {
+		t.Errorf("got running agents %v, want hana and kai", running)
+	}
+}
@@ -0,0 +1,87 @@
+package agents
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/soralabs/hana/internal/twitter"
+	"github.com/soralabs/zen/logger"
+	"github.com/soralabs/zen/manager"
+	"github.com/soralabs/zen/options"
+)
+
+// Runner runs several agent accounts in one process.
+// Agents share the dependencies passed with WithAgentOptions, the database and LLM client among them,
+// while each keeps its own persona, Twitter account, managers and schema.
+// The tipper is shared too, so all agents tip from one wallet within one daily budget.
+type Runner struct {
+	options.RequiredFields
+
+	ctx    context.Context
+	logger *logger.Logger
+	config *Config
+	// agentOpts are applied to every agent before the options of its config
+	agentOpts []options.Option[twitter.Twitter]
+
+	// newAgent creates an agent, twitter.New unless a test replaces it
+	newAgent func(opts ...options.Option[twitter.Twitter]) (agent, error)
+
+	mu      sync.Mutex
+	running map[string]*runningAgent
+}
+
+// agent is what the runner starts and stops, a *twitter.Twitter outside of tests
+type agent interface {
+	Start() error
+	Stop() error
+	ReloadPersona() error
+}
+
+// runningAgent is a started agent and the cancel function of its context
+type runningAgent struct {
+	agent  agent
+	cancel context.CancelFunc
+}
+
+// Config is the agents file
+type Config struct {
+	Agents []AgentConfig `yaml:"agents"`
+}
+
+// AgentConfig is an agent account
+type AgentConfig struct {
+	// Name identifies the agent in the logs
+	Name string `yaml:"name"`
+	// Disabled agents are not started
+	Disabled bool `yaml:"disabled"`
+	// Assistant is what the agent's assistant ID is derived from, defaults to the name
+	Assistant string `yaml:"assistant"`
+	// Schema is the Postgres schema of the agent's tables, defaults to the name.
+	// An empty schema uses the database's default schema, which keeps the memories of an agent that ran before.
+	Schema *string `yaml:"schema"`
+
+	Persona string        `yaml:"persona"`
+	Twitter TwitterConfig `yaml:"twitter"`
+
+	MonitorInterval IntervalConfig `yaml:"monitor_interval"`
+	TweetInterval   IntervalConfig `yaml:"tweet_interval"`
+
+	// Managers are the optional managers the agent runs, all of them when left out
+	Managers []manager.ManagerID `yaml:"managers"`
+	// DryRun is the file the agent records its tweets to instead of posting them
+	DryRun string `yaml:"dry_run"`
+}
+
+// TwitterConfig is the account an agent tweets from
+type TwitterConfig struct {
+	User      string `yaml:"user"`
+	CT0       string `yaml:"ct0"`
+	AuthToken string `yaml:"auth_token"`
+}
+
+// IntervalConfig is a range of durations, like "4h" to "12h"
+type IntervalConfig struct {
+	Min time.Duration `yaml:"min"`
+	Max time.Duration `yaml:"max"`
+}
@@ -0,0 +1,158 @@
+package approval
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/soralabs/zen/id"
+)
+
+// maxListLimit bounds how many posts a list request returns
+const maxListLimit = 100
+
+// routes returns the admin API:
+//
+//	GET  /posts?status=pending&limit=50  lists posts, newest first
+//	GET  /posts/{id}                     returns a post
+//	POST /posts/{id}/approve             posts it, {"content": "..."} replaces the generated content
+//	POST /posts/{id}/reject              drops it, {"reason": "..."} is recorded
+func (q *Queue) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /posts", q.handleList)
+	mux.HandleFunc("GET /posts/{id}", q.handleGet)
+	mux.HandleFunc("POST /posts/{id}/approve", q.handleApprove)
+	mux.HandleFunc("POST /posts/{id}/reject", q.handleReject)
+	return q.authorize(mux)
+}
+
+// authorize rejects requests without the admin token
+func (q *Queue) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if q.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(q.adminToken)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (q *Queue) handleList(w http.ResponseWriter, r *http.Request) {
+	limit := maxListLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("limit must be a positive number"))
+			return
+		}
+		limit = min(parsed, maxListLimit)
+	}
+
+	posts, err := q.List(PostStatus(r.URL.Query().Get("status")), limit)
+	if err != nil {
+		q.writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, posts)
+}
+
+func (q *Queue) handleGet(w http.ResponseWriter, r *http.Request) {
+	postID, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := q.Get(postID)
+	if err != nil {
+		q.writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, post)
+}
+
+func (q *Queue) handleApprove(w http.ResponseWriter, r *http.Request) {
+	postID, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
+	request, ok := decodeDecision(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := q.Approve(postID, request.Content)
+	if err != nil {
+		q.writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, post)
+}
+
+func (q *Queue) handleReject(w http.ResponseWriter, r *http.Request) {
+	postID, ok := parsePostID(w, r)
+	if !ok {
+		return
+	}
+	request, ok := decodeDecision(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := q.Reject(postID, request.Reason)
+	if err != nil {
+		q.writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, post)
+}
+
+// parsePostID reads the post ID from the path
+func parsePostID(w http.ResponseWriter, r *http.Request) (id.ID, bool) {
+	parsed, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid post id"))
+		return "", false
+	}
+	return id.ID(parsed.String()), true
+}
+
+// decodeDecision reads the optional body of approve and reject requests
+func decodeDecision(w http.ResponseWriter, r *http.Request) (decisionRequest, bool) {
+	var request decisionRequest
+	if r.ContentLength == 0 {
+		return request, true
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+		return request, false
+	}
+	return request, true
+}
+
+// writeFailure maps queue errors to status codes, hiding internal errors from the response
+func (q *Queue) writeFailure(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrNotPending):
+		writeError(w, http.StatusConflict, err)
+	default:
+		q.logger.Warnf("approval API request failed: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
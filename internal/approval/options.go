@@ -0,0 +1,79 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/soralabs/zen/logger"
+	"github.com/soralabs/zen/options"
+	"gorm.io/gorm"
+)
+
+// ValidateRequiredFields checks the dependencies and limits of the queue
+func (q *Queue) ValidateRequiredFields() error {
+	if q.ctx == nil {
+		return fmt.Errorf("context is required")
+	}
+	if q.logger == nil {
+		return fmt.Errorf("logger is required")
+	}
+	if q.database == nil {
+		return fmt.Errorf("database is required")
+	}
+	if q.listenAddr == "" {
+		return fmt.Errorf("listen address is required")
+	}
+	if q.expireAfter <= 0 {
+		return fmt.Errorf("expiry must be positive")
+	}
+	return nil
+}
+
+// WithContext sets the context
+func WithContext(ctx context.Context) options.Option[Queue] {
+	return func(q *Queue) error {
+		q.ctx = ctx
+		return nil
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger *logger.Logger) options.Option[Queue] {
+	return func(q *Queue) error {
+		q.logger = logger
+		return nil
+	}
+}
+
+// WithDatabase sets the database the queue table lives in
+func WithDatabase(database *gorm.DB) options.Option[Queue] {
+	return func(q *Queue) error {
+		q.database = database
+		return nil
+	}
+}
+
+// WithListenAddr sets where the admin API listens, defaults to DefaultListenAddr
+func WithListenAddr(addr string) options.Option[Queue] {
+	return func(q *Queue) error {
+		q.listenAddr = addr
+		return nil
+	}
+}
+
+// WithAdminToken requires the token as a bearer token on every admin request, Start fails without one
+func WithAdminToken(token string) options.Option[Queue] {
+	return func(q *Queue) error {
+		q.adminToken = token
+		return nil
+	}
+}
+
+// WithExpireAfter sets how long a post can wait for approval, defaults to DefaultExpireAfter
+func WithExpireAfter(expireAfter time.Duration) options.Option[Queue] {
+	return func(q *Queue) error {
+		q.expireAfter = expireAfter
+		return nil
+	}
+}
@@ -0,0 +1,301 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/options"
+	"gorm.io/gorm"
+)
+
+const (
+	PostKindReply       PostKind = "reply"
+	PostKindTweet       PostKind = "tweet"
+	PostKindCannedReply PostKind = "canned_reply"
+)
+
+const (
+	PostStatusPending  PostStatus = "pending"
+	PostStatusApproved PostStatus = "approved"
+	PostStatusPosted   PostStatus = "posted"
+	PostStatusRejected PostStatus = "rejected"
+	PostStatusExpired  PostStatus = "expired"
+	PostStatusFailed   PostStatus = "failed"
+)
+
+const (
+	// DefaultListenAddr only accepts connections from the local machine
+	DefaultListenAddr = "127.0.0.1:8089"
+	// DefaultExpireAfter drops replies once the conversation has likely moved on
+	DefaultExpireAfter = 2 * time.Hour
+
+	// expiryInterval is how often pending posts are checked for expiry
+	expiryInterval = time.Minute
+	// shutdownTimeout bounds how long in-flight admin requests get to finish on Stop
+	shutdownTimeout = 30 * time.Second
+)
+
+var (
+	ErrNotFound   = errors.New("post not found")
+	ErrNotPending = errors.New("post is not pending")
+	// ErrNoAdminToken is returned by Start when the admin API has no token to require
+	ErrNoAdminToken = errors.New("admin token is required to start the approval API")
+)
+
+// New creates an approval queue and its table if it doesn't exist yet.
+// Posts left pending by a previous run are expired since they can't be posted anymore.
+func New(opts ...options.Option[Queue]) (*Queue, error) {
+	q := &Queue{
+		listenAddr:  DefaultListenAddr,
+		expireAfter: DefaultExpireAfter,
+		publishers:  make(map[id.ID]PublishFunc),
+		stopChan:    make(chan struct{}),
+	}
+
+	if err := options.ApplyOptions(q, opts...); err != nil {
+		return nil, fmt.Errorf("failed to apply options: %w", err)
+	}
+
+	if err := q.database.AutoMigrate(&Post{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate approval queue: %w", err)
+	}
+
+	if err := q.database.Model(&Post{}).
+		Where("status = ?", PostStatusPending).
+		Updates(map[string]interface{}{
+			"status":     PostStatusExpired,
+			"note":       "the agent restarted before the post was approved",
+			"decided_at": time.Now(),
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to expire posts of the previous run: %w", err)
+	}
+
+	return q, nil
+}
+
+// Start launches the admin API and the expiry loop
+func (q *Queue) Start() error {
+	// the API posts as the agent, it is never served without authentication
+	if q.adminToken == "" {
+		return ErrNoAdminToken
+	}
+
+	q.server = &http.Server{
+		Addr:              q.listenAddr,
+		Handler:           q.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	q.wg.Add(2)
+	go func() {
+		defer q.wg.Done()
+		q.logger.Infof("Approval API listening on %s", q.listenAddr)
+		if err := q.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			q.logger.Errorf("Approval API stopped: %v", err)
+		}
+	}()
+	go func() {
+		defer q.wg.Done()
+		q.expiryLoop()
+	}()
+
+	return nil
+}
+
+// Stop shuts down the admin API, letting in-flight approvals finish, and stops the expiry loop.
+// Safe to call more than once.
+func (q *Queue) Stop() error {
+	q.stopOnce.Do(func() {
+		close(q.stopChan)
+	})
+
+	var err error
+	if q.server != nil {
+		// the queue's context may already be canceled at shutdown, the drain gets its own deadline
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if shutdownErr := q.server.Shutdown(ctx); shutdownErr != nil {
+			err = fmt.Errorf("failed to shut down approval API: %w", shutdownErr)
+		}
+	}
+
+	q.wg.Wait()
+	return err
+}
+
+// Enqueue stores a post for approval, publish is called with the approved content
+func (q *Queue) Enqueue(post *Post, publish PublishFunc) error {
+	now := time.Now()
+	post.ID = id.New()
+	post.GeneratedContent = post.Content
+	post.Status = PostStatusPending
+	post.ExpiresAt = now.Add(q.expireAfter)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.database.Create(post).Error; err != nil {
+		return fmt.Errorf("failed to enqueue post: %w", err)
+	}
+	q.publishers[post.ID] = publish
+
+	q.logger.WithFields(map[string]interface{}{
+		"post_id":    post.ID,
+		"kind":       post.Kind,
+		"content":    post.Content,
+		"expires_at": post.ExpiresAt,
+	}).Infof("Post waiting for approval")
+
+	return nil
+}
+
+// List returns the posts with the given status, newest first, or every post when status is empty
+func (q *Queue) List(status PostStatus, limit int) ([]Post, error) {
+	query := q.database.Order("created_at DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var posts []Post
+	if err := query.Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+	return posts, nil
+}
+
+// Get returns a post by ID
+func (q *Queue) Get(postID id.ID) (*Post, error) {
+	var post Post
+	if err := q.database.First(&post, "id = ?", postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	return &post, nil
+}
+
+// Approve posts a pending post, with content replacing the generated content when it isn't empty.
+// The post is claimed before publishing so it is never posted twice.
+func (q *Queue) Approve(postID id.ID, content string) (*Post, error) {
+	post, publish, err := q.claim(postID, PostStatusApproved, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if content = strings.TrimSpace(content); content != "" {
+		post.Content = content
+	}
+
+	status, note := PostStatusPosted, ""
+	if err := publish(post.Content); err != nil {
+		status, note = PostStatusFailed, err.Error()
+		q.logger.Warnf("failed to publish approved post %s: %v", post.ID, err)
+	}
+
+	post.Status = status
+	post.Note = note
+	if err := q.database.Model(post).Updates(map[string]interface{}{
+		"content": post.Content,
+		"status":  post.Status,
+		"note":    post.Note,
+	}).Error; err != nil {
+		return post, fmt.Errorf("failed to update post: %w", err)
+	}
+
+	return post, nil
+}
+
+// Reject drops a pending post without posting it
+func (q *Queue) Reject(postID id.ID, reason string) (*Post, error) {
+	post, _, err := q.claim(postID, PostStatusRejected, reason)
+	return post, err
+}
+
+// claim moves a pending post to its decided status and takes its publisher out of the queue
+func (q *Queue) claim(postID id.ID, status PostStatus, note string) (*Post, PublishFunc, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	post, err := q.Get(postID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publish, exists := q.publishers[postID]
+	if post.Status != PostStatusPending || !exists {
+		return nil, nil, ErrNotPending
+	}
+
+	now := time.Now()
+	post.Status = status
+	post.Note = note
+	post.DecidedAt = &now
+	if err := q.database.Model(post).Updates(map[string]interface{}{
+		"status":     post.Status,
+		"note":       post.Note,
+		"decided_at": post.DecidedAt,
+	}).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to update post: %w", err)
+	}
+	delete(q.publishers, postID)
+
+	return post, publish, nil
+}
+
+// expiryLoop expires stale posts until the queue is stopped
+func (q *Queue) expiryLoop() {
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stopChan:
+			return
+		case <-ticker.C:
+			if err := q.expire(time.Now()); err != nil {
+				q.logger.Warnf("failed to expire posts: %v", err)
+			}
+		}
+	}
+}
+
+// expire marks pending posts past their expiry as expired so they can't be approved anymore
+func (q *Queue) expire(now time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var expired []Post
+	if err := q.database.Where("status = ? AND expires_at < ?", PostStatusPending, now).Find(&expired).Error; err != nil {
+		return fmt.Errorf("failed to find stale posts: %w", err)
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	ids := make([]id.ID, 0, len(expired))
+	for _, post := range expired {
+		ids = append(ids, post.ID)
+	}
+
+	if err := q.database.Model(&Post{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":     PostStatusExpired,
+			"decided_at": now,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to expire posts: %w", err)
+	}
+
+	for _, postID := range ids {
+		delete(q.publishers, postID)
+	}
+	q.logger.Infof("Expired %d posts waiting for approval", len(ids))
+
+	return nil
+}
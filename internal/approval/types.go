@@ -0,0 +1,88 @@
+package approval
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/logger"
+	"github.com/soralabs/zen/options"
+	"gorm.io/gorm"
+)
+
+// Queue holds generated tweets until someone approves, edits or rejects them through the admin API.
+// Posts are persisted for review, but the conversation state needed to post them only lives in memory,
+// so posts left pending by a previous run are expired on startup.
+type Queue struct {
+	options.RequiredFields
+
+	ctx      context.Context
+	logger   *logger.Logger
+	database *gorm.DB
+
+	// listenAddr is where the admin API listens, it should stay on a local interface
+	listenAddr string
+	// adminToken is required as a bearer token on every admin request, the API doesn't start without one
+	adminToken string
+	// expireAfter is how long a post can wait for approval before it is too stale to post
+	expireAfter time.Duration
+
+	mu sync.Mutex
+	// publishers post the approved content of each pending post
+	publishers map[id.ID]PublishFunc
+
+	server   *http.Server
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// PublishFunc posts the approved content, which differs from the generated content when it was edited
+type PublishFunc func(content string) error
+
+// PostKind is what kind of tweet is waiting for approval
+type PostKind string
+
+// PostStatus is the state of a post in the queue
+type PostStatus string
+
+// Post is a generated tweet waiting for approval along with what it was generated from
+type Post struct {
+	ID   id.ID    `gorm:"type:uuid;primaryKey" json:"id"`
+	Kind PostKind `gorm:"type:varchar(16);not null" json:"kind"`
+	// Content is what gets posted, GeneratedContent keeps the original when it was edited
+	Content          string `gorm:"type:text;not null" json:"content"`
+	GeneratedContent string `gorm:"type:text;not null" json:"generated_content"`
+	ThoughtProcess   string `gorm:"type:text" json:"thought_process"`
+	// GuardrailsResult is the output guardrails verdict as JSON
+	GuardrailsResult string `gorm:"type:text" json:"guardrails_result"`
+
+	// Source is the tweet being replied to, empty for standalone tweets
+	SourceTweetID  string `gorm:"type:varchar(64)" json:"source_tweet_id,omitempty"`
+	SourceUsername string `gorm:"type:varchar(255)" json:"source_username,omitempty"`
+	SourceText     string `gorm:"type:text" json:"source_text,omitempty"`
+
+	Status PostStatus `gorm:"type:varchar(16);not null;index" json:"status"`
+	// Note is the rejection reason or the error of a failed post
+	Note string `gorm:"type:text" json:"note,omitempty"`
+
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName keeps the queue in the approval_queue table
+func (Post) TableName() string {
+	return "approval_queue"
+}
+
+// decisionRequest is the body of approve and reject requests
+type decisionRequest struct {
+	// Content replaces the generated content on approval when set
+	Content string `json:"content"`
+	// Reason explains a rejection
+	Reason string `json:"reason"`
+}
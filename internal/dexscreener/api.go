@@ -2,30 +2,88 @@ package dexscreener
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-
-	"github.com/go-resty/resty/v2"
+	"net/url"
+	"strings"
 )
 
-func GetPairInformation(ctx context.Context, tokenAddress string) ([]PairInformation, error) {
-	client := resty.New()
+// maxTokensPerRequest is the most token addresses the tokens endpoint accepts at once
+const maxTokensPerRequest = 30
+
+// Search returns the pairs matching a query, such as a token address, symbol or name
+func (c *Client) Search(ctx context.Context, query string) ([]PairInformation, error) {
+	var response PairInformationResponse
+	if err := c.get(ctx, "/latest/dex/search?q="+url.QueryEscape(query), &response); err != nil {
+		return nil, err
+	}
+	return response.Pairs, nil
+}
+
+// Pair returns a single pair by chain and pair address
+func (c *Client) Pair(ctx context.Context, chainID, pairAddress string) (*PairInformation, error) {
+	var response PairInformationResponse
+	if err := c.get(ctx, fmt.Sprintf("/latest/dex/pairs/%s/%s", url.PathEscape(chainID), url.PathEscape(pairAddress)), &response); err != nil {
+		return nil, err
+	}
+	if len(response.Pairs) == 0 {
+		return nil, fmt.Errorf("pair %s not found on %s", pairAddress, chainID)
+	}
+	return &response.Pairs[0], nil
+}
+
+// TokenPairs returns all pools of a token on a chain
+func (c *Client) TokenPairs(ctx context.Context, chainID, tokenAddress string) ([]PairInformation, error) {
+	var pairs []PairInformation
+	if err := c.get(ctx, fmt.Sprintf("/token-pairs/v1/%s/%s", url.PathEscape(chainID), url.PathEscape(tokenAddress)), &pairs); err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// Tokens returns the pairs of up to 30 tokens on a chain in a single request
+func (c *Client) Tokens(ctx context.Context, chainID string, tokenAddresses ...string) ([]PairInformation, error) {
+	if len(tokenAddresses) == 0 {
+		return nil, nil
+	}
+	if len(tokenAddresses) > maxTokensPerRequest {
+		return nil, fmt.Errorf("at most %d token addresses can be requested at once, got %d", maxTokensPerRequest, len(tokenAddresses))
+	}
 
-	requestUrl := fmt.Sprintf("https://api.dexscreener.com/latest/dex/search?q=%s", tokenAddress)
+	escaped := make([]string, 0, len(tokenAddresses))
+	for _, address := range tokenAddresses {
+		escaped = append(escaped, url.PathEscape(address))
+	}
 
-	headers := map[string]string{
-		"user-agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	var pairs []PairInformation
+	if err := c.get(ctx, fmt.Sprintf("/tokens/v1/%s/%s", url.PathEscape(chainID), strings.Join(escaped, ",")), &pairs); err != nil {
+		return nil, err
 	}
+	return pairs, nil
+}
 
-	response, err := client.R().SetContext(ctx).SetHeaders(headers).Get(requestUrl)
-	if err != nil {
+// LatestTokenProfiles returns the most recently updated token profiles
+func (c *Client) LatestTokenProfiles(ctx context.Context) ([]TokenProfile, error) {
+	var profiles []TokenProfile
+	if err := c.get(ctx, "/token-profiles/latest/v1", &profiles); err != nil {
 		return nil, err
 	}
+	return profiles, nil
+}
 
-	var pairInformationResponse PairInformationResponse
-	if err := json.Unmarshal(response.Body(), &pairInformationResponse); err != nil {
+// LatestTokenBoosts returns the most recently boosted tokens
+func (c *Client) LatestTokenBoosts(ctx context.Context) ([]TokenBoost, error) {
+	var boosts []TokenBoost
+	if err := c.get(ctx, "/token-boosts/latest/v1", &boosts); err != nil {
 		return nil, err
 	}
+	return boosts, nil
+}
 
-	return pairInformationResponse.Pairs, nil
+// TopTokenBoosts returns the tokens with the most active boosts
+func (c *Client) TopTokenBoosts(ctx context.Context) ([]TokenBoost, error) {
+	var boosts []TokenBoost
+	if err := c.get(ctx, "/token-boosts/top/v1", &boosts); err != nil {
+		return nil, err
+	}
+	return boosts, nil
 }
@@ -0,0 +1,69 @@
+package dexscreener
+
+import (
+	"sync"
+	"time"
+)
+
+// responseCache keeps the raw response bodies of successful requests keyed by endpoint and params
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+
+	// ttl is how long a response is fresh
+	ttl time.Duration
+	// maxStale is how long a response is served while it can't be refreshed
+	maxStale time.Duration
+}
+
+type cacheEntry struct {
+	body      []byte
+	fetchedAt time.Time
+}
+
+func newResponseCache(ttl, maxStale time.Duration) *responseCache {
+	return &responseCache{
+		entries:  make(map[string]cacheEntry),
+		ttl:      ttl,
+		maxStale: maxStale,
+	}
+}
+
+// get returns the cached body and whether it is still fresh.
+// Bodies older than ttl plus maxStale are not returned.
+func (c *responseCache) get(key string) (body []byte, fresh bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.entries[key]
+	if !exists {
+		return nil, false, false
+	}
+
+	age := time.Since(entry.fetchedAt)
+	if age > c.ttl+c.maxStale {
+		delete(c.entries, key)
+		return nil, false, false
+	}
+
+	return entry.body, age <= c.ttl, true
+}
+
+func (c *responseCache) set(key string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	// drop entries that can't be served anymore so the cache doesn't grow with one-off queries
+	for k, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) > c.ttl+c.maxStale {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = cacheEntry{
+		body:      body,
+		fetchedAt: now,
+	}
+}
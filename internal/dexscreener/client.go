@@ -0,0 +1,215 @@
+package dexscreener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/soralabs/zen/options"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	DefaultBaseURL = "https://api.dexscreener.com"
+
+	defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+
+	// maxErrorBodyLength caps how much of an error response ends up in a StatusError
+	maxErrorBodyLength = 256
+
+	// fetchTimeout bounds a shared fetch including retries, every attempt is bounded by the client timeout
+	fetchTimeout = time.Minute
+)
+
+// Client is a reusable DexScreener API client.
+// Requests are rate limited per endpoint group, concurrent identical requests share a single
+// call and responses are cached, stale responses are served while DexScreener can't be reached.
+type Client struct {
+	options.RequiredFields
+
+	baseURL       string
+	timeout       time.Duration
+	maxRetries    int
+	retryBackoff  time.Duration
+	rateLimits    RateLimits
+	cacheTTL      time.Duration
+	cacheMaxStale time.Duration
+
+	http     *resty.Client
+	limiters *limiters
+	cache    *responseCache
+	inflight singleflight.Group
+}
+
+// NewClient creates a DexScreener client, by default against the public API
+func NewClient(opts ...options.Option[Client]) (*Client, error) {
+	c := &Client{
+		baseURL:       DefaultBaseURL,
+		timeout:       10 * time.Second,
+		maxRetries:    3,
+		retryBackoff:  500 * time.Millisecond,
+		rateLimits:    DefaultRateLimits,
+		cacheTTL:      30 * time.Second,
+		cacheMaxStale: time.Hour,
+	}
+
+	if err := options.ApplyOptions(c, opts...); err != nil {
+		return nil, fmt.Errorf("failed to apply options: %w", err)
+	}
+
+	c.http = resty.New().
+		SetBaseURL(c.baseURL).
+		SetTimeout(c.timeout).
+		SetHeader("user-agent", defaultUserAgent).
+		SetHeader("accept", "application/json")
+	c.limiters = newLimiters(c.rateLimits)
+	c.cache = newResponseCache(c.cacheTTL, c.cacheMaxStale)
+
+	return c, nil
+}
+
+// get returns the response of a GET request decoded into result.
+// Fresh cached responses are returned as is. Stale ones are returned right away while
+// they are refreshed in the background, so an outage keeps serving the last good data.
+func (c *Client) get(ctx context.Context, path string, result interface{}) error {
+	body, fresh, cached := c.cache.get(path)
+	switch {
+	case cached && fresh:
+	case cached:
+		c.refresh(path)
+	default:
+		var err error
+		body, err = c.fetch(ctx, path)
+		if err != nil {
+			return fmt.Errorf("failed to get %s: %w", path, err)
+		}
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+
+	return nil
+}
+
+// fetch requests a path and caches the response.
+// Concurrent fetches of the same path share one request, which isn't canceled with the context
+// of the caller that started it. Every caller stops waiting once its own context is done.
+func (c *Client) fetch(ctx context.Context, path string) ([]byte, error) {
+	result := c.inflight.DoChan(path, func() (interface{}, error) {
+		sharedCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+
+		body, err := c.fetchWithRetries(sharedCtx, path)
+		if err != nil {
+			return nil, err
+		}
+		c.cache.set(path, body)
+		return body, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	}
+}
+
+// refresh fetches a path in the background, failures keep the stale response cached
+func (c *Client) refresh(path string) {
+	go func() {
+		_, _ = c.fetch(context.Background(), path)
+	}()
+}
+
+// fetchWithRetries retries rate limits, server errors and transport errors with exponential
+// backoff, other statuses fail immediately
+func (c *Client) fetchWithRetries(ctx context.Context, path string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.limiters.forPath(path).Wait(ctx); err != nil {
+			return nil, fmt.Errorf("failed to wait for rate limiter: %w", err)
+		}
+
+		body, err := c.do(ctx, path)
+		if err == nil {
+			return body, nil
+		}
+		if attempt >= c.maxRetries || !isRetryable(err) {
+			return nil, err
+		}
+
+		wait := c.retryBackoff << attempt
+		var rateLimitErr *RateLimitError
+		if errors.As(err, &rateLimitErr) && rateLimitErr.RetryAfter > wait {
+			wait = rateLimitErr.RetryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, path string) ([]byte, error) {
+	response, err := c.http.R().SetContext(ctx).Get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch response.StatusCode() {
+	case http.StatusOK:
+	case http.StatusTooManyRequests:
+		return nil, &RateLimitError{RetryAfter: parseRetryAfter(response.Header().Get("Retry-After"))}
+	default:
+		body := response.String()
+		if len(body) > maxErrorBodyLength {
+			body = body[:maxErrorBodyLength]
+		}
+		return nil, &StatusError{StatusCode: response.StatusCode(), Body: body}
+	}
+
+	if !json.Valid(response.Body()) {
+		return nil, fmt.Errorf("response is not valid json")
+	}
+
+	return response.Body(), nil
+}
+
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.retryable()
+	}
+
+	// transport errors and bodies that fail to decode are usually transient
+	return true
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,244 @@
+package dexscreener
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testTTL is how long responses of the test clients are fresh
+const testTTL = 20 * time.Millisecond
+
+// testResponse is an answer of the test server
+type testResponse struct {
+	status     int
+	retryAfter int
+	body       string
+}
+
+func ok(value string) testResponse {
+	return testResponse{status: http.StatusOK, body: `{"value":"` + value + `"}`}
+}
+
+// testServer answers with its responses in order, repeating the last one
+type testServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses []testResponse
+	requests  int
+}
+
+func newTestServer(t *testing.T, responses ...testResponse) *testServer {
+	s := &testServer{responses: responses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		response := s.responses[min(s.requests, len(s.responses)-1)]
+		s.requests++
+		s.mu.Unlock()
+
+		if response.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(response.retryAfter))
+		}
+		w.WriteHeader(response.status)
+		w.Write([]byte(response.body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func newTestClient(t *testing.T, baseURL string, maxRetries int) *Client {
+	c, err := NewClient(
+		WithBaseURL(baseURL),
+		WithRetries(maxRetries, time.Millisecond),
+		WithCache(testTTL, time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return c
+}
+
+func getValue(ctx context.Context, c *Client) (string, error) {
+	var result struct {
+		Value string `json:"value"`
+	}
+	err := c.get(ctx, "/latest/dex/search?q=sora", &result)
+	return result.Value, err
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name       string
+		responses  []testResponse
+		maxRetries int
+		// stale gets the first response and lets it go stale before the checked get
+		stale         bool
+		want          string
+		wantRateLimit bool
+		wantMinWait   time.Duration
+		// wantRequests is how many requests reach the server, background refreshes included
+		wantRequests int
+		// wantAfter is what a get returns once every request is done
+		wantAfter string
+	}{
+		{
+			name:         "fresh response is cached",
+			responses:    []testResponse{ok("1"), ok("2")},
+			want:         "1",
+			wantRequests: 1,
+			wantAfter:    "1",
+		},
+		{
+			name:         "stale response is served and refreshed",
+			responses:    []testResponse{ok("1"), ok("2")},
+			stale:        true,
+			want:         "1",
+			wantRequests: 2,
+			wantAfter:    "2",
+		},
+		{
+			name:         "stale response is served while the refresh fails",
+			responses:    []testResponse{ok("1"), {status: http.StatusBadGateway, body: "bad gateway"}},
+			stale:        true,
+			want:         "1",
+			wantRequests: 2,
+			wantAfter:    "1",
+		},
+		{
+			name:         "rate limit is retried after backoff",
+			responses:    []testResponse{{status: http.StatusTooManyRequests}, ok("1")},
+			maxRetries:   1,
+			want:         "1",
+			wantRequests: 2,
+			wantAfter:    "1",
+		},
+		{
+			name:         "rate limit waits as long as asked",
+			responses:    []testResponse{{status: http.StatusTooManyRequests, retryAfter: 1}, ok("1")},
+			maxRetries:   1,
+			want:         "1",
+			wantMinWait:  time.Second,
+			wantRequests: 2,
+			wantAfter:    "1",
+		},
+		{
+			name:          "rate limit fails once the retries are used up",
+			responses:     []testResponse{{status: http.StatusTooManyRequests}},
+			maxRetries:    2,
+			wantRateLimit: true,
+			wantRequests:  3,
+		},
+		{
+			name:         "client errors are not retried",
+			responses:    []testResponse{{status: http.StatusNotFound, body: "not found"}, ok("1")},
+			maxRetries:   2,
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, tt.responses...)
+			c := newTestClient(t, server.URL, tt.maxRetries)
+			ctx := context.Background()
+
+			if tt.stale {
+				if _, err := getValue(ctx, c); err != nil {
+					t.Fatalf("failed to prime the cache: %v", err)
+				}
+				time.Sleep(2 * testTTL)
+			}
+
+			start := time.Now()
+			got, err := getValue(ctx, c)
+			elapsed := time.Since(start)
+
+			var rateLimitErr *RateLimitError
+			switch {
+			case tt.wantRateLimit && !errors.As(err, &rateLimitErr):
+				t.Fatalf("got error %v, want a rate limit error", err)
+			case tt.want == "" && err == nil:
+				t.Fatalf("got %q, want an error", got)
+			case tt.want != "" && err != nil:
+				t.Fatalf("get: %v", err)
+			case got != tt.want:
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if elapsed < tt.wantMinWait {
+				t.Errorf("got a response after %v, want at least %v", elapsed, tt.wantMinWait)
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for server.requestCount() < tt.wantRequests && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			// lets a refresh that got its response store it
+			time.Sleep(10 * time.Millisecond)
+			if requests := server.requestCount(); requests != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", requests, tt.wantRequests)
+			}
+
+			if tt.wantAfter != "" {
+				got, err := getValue(ctx, c)
+				if err != nil {
+					t.Fatalf("get after the requests: %v", err)
+				}
+				if got != tt.wantAfter {
+					t.Errorf("got %q after the requests, want %q", got, tt.wantAfter)
+				}
+			}
+		})
+	}
+}
+
+// TestSharedFetchOutlivesItsCaller cancels the caller that started a fetch another caller waits on
+func TestSharedFetchOutlivesItsCaller(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+		w.Write([]byte(`{"value":"1"}`))
+	}))
+	defer server.Close()
+	c := newTestClient(t, server.URL, 0)
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := getValue(firstCtx, c)
+		first <- err
+	}()
+	<-received
+
+	second := make(chan string, 1)
+	go func() {
+		value, err := getValue(context.Background(), c)
+		if err != nil {
+			t.Errorf("second caller: %v", err)
+		}
+		second <- value
+	}()
+
+	cancelFirst()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v for the canceled caller, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	if value := <-second; value != "1" {
+		t.Errorf("got %q for the second caller, want %q", value, "1")
+	}
+}
@@ -0,0 +1,35 @@
+package dexscreener
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimitError is returned when DexScreener answers with 429 Too Many Requests
+type RateLimitError struct {
+	// RetryAfter is how long DexScreener asked to wait, zero if it didn't say
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("dexscreener rate limit exceeded, retry after %v", e.RetryAfter)
+	}
+	return "dexscreener rate limit exceeded"
+}
+
+// StatusError is returned when DexScreener answers with a status other than 200 or 429
+type StatusError struct {
+	StatusCode int
+	// Body is the start of the response body, which is often an HTML error page
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("dexscreener returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// retryable reports whether the request may succeed when sent again
+func (e *StatusError) retryable() bool {
+	return e.StatusCode >= 500
+}
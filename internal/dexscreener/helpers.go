@@ -0,0 +1,26 @@
+package dexscreener
+
+import "fmt"
+
+// HighestLiquidityPair returns the pair on the given chain with the most USD liquidity
+// where the token is the base token. Search results are not ordered by relevance,
+// so the first hit is often a thin or unrelated pair.
+// Solana addresses are base58, so the token address is matched case-sensitively.
+func HighestLiquidityPair(pairs []PairInformation, chainID, tokenAddress string) (*PairInformation, error) {
+	var best *PairInformation
+	for i := range pairs {
+		pair := &pairs[i]
+		if pair.ChainID != chainID || pair.BaseToken.Address != tokenAddress {
+			continue
+		}
+		if best == nil || pair.Liquidity.Usd > best.Liquidity.Usd {
+			best = pair
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no %s pair found for %s", chainID, tokenAddress)
+	}
+
+	return best, nil
+}
@@ -0,0 +1,50 @@
+package dexscreener
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimits are the requests per minute allowed for each group of endpoints
+type RateLimits struct {
+	// Pairs covers search, pairs, tokens and token pairs
+	Pairs int
+	// Profiles covers token profiles, boosts and orders
+	Profiles int
+}
+
+// DefaultRateLimits match the public API limits
+var DefaultRateLimits = RateLimits{
+	Pairs:    300,
+	Profiles: 60,
+}
+
+// limiters holds a token bucket per endpoint group
+type limiters struct {
+	pairs    *rate.Limiter
+	profiles *rate.Limiter
+}
+
+func newLimiters(limits RateLimits) *limiters {
+	return &limiters{
+		pairs:    newLimiter(limits.Pairs),
+		profiles: newLimiter(limits.Profiles),
+	}
+}
+
+// newLimiter allows a full minute's worth of requests as a burst, refilled evenly over the minute
+func newLimiter(perMinute int) *rate.Limiter {
+	return rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), perMinute)
+}
+
+// forPath returns the limiter of the endpoint group a path belongs to
+func (l *limiters) forPath(path string) *rate.Limiter {
+	for _, prefix := range []string{"/token-profiles/", "/token-boosts/", "/orders/"} {
+		if strings.HasPrefix(path, prefix) {
+			return l.profiles
+		}
+	}
+	return l.pairs
+}
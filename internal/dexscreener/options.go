@@ -0,0 +1,72 @@
+package dexscreener
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/soralabs/zen/options"
+)
+
+// ValidateRequiredFields checks the client configuration
+func (c *Client) ValidateRequiredFields() error {
+	if c.baseURL == "" {
+		return fmt.Errorf("base url is required")
+	}
+	if c.timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	if c.maxRetries < 0 || c.retryBackoff < 0 {
+		return fmt.Errorf("retries and backoff can't be negative")
+	}
+	if c.rateLimits.Pairs <= 0 || c.rateLimits.Profiles <= 0 {
+		return fmt.Errorf("rate limits must be positive")
+	}
+	if c.cacheTTL < 0 || c.cacheMaxStale < 0 {
+		return fmt.Errorf("cache durations can't be negative")
+	}
+	return nil
+}
+
+// WithBaseURL points the client at another DexScreener compatible API
+func WithBaseURL(baseURL string) options.Option[Client] {
+	return func(c *Client) error {
+		c.baseURL = baseURL
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of a single request attempt
+func WithTimeout(timeout time.Duration) options.Option[Client] {
+	return func(c *Client) error {
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithRetries sets how often failed requests are retried and the initial backoff,
+// which doubles after every attempt
+func WithRetries(maxRetries int, backoff time.Duration) options.Option[Client] {
+	return func(c *Client) error {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+		return nil
+	}
+}
+
+// WithRateLimits sets the requests per minute allowed for each group of endpoints
+func WithRateLimits(limits RateLimits) options.Option[Client] {
+	return func(c *Client) error {
+		c.rateLimits = limits
+		return nil
+	}
+}
+
+// WithCache sets how long responses are fresh and how much longer a stale response
+// is served while it can't be refreshed
+func WithCache(ttl, maxStale time.Duration) options.Option[Client] {
+	return func(c *Client) error {
+		c.cacheTTL = ttl
+		c.cacheMaxStale = maxStale
+		return nil
+	}
+}
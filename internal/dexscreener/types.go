@@ -75,3 +75,34 @@ type PairInformationResponse struct {
 	SchemaVersion string            `json:"schemaVersion"`
 	Pairs         []PairInformation `json:"pairs"`
 }
+
+// TokenLink is a website or social link of a token profile or boost
+type TokenLink struct {
+	Type  string `json:"type,omitempty"`
+	Label string `json:"label,omitempty"`
+	URL   string `json:"url"`
+}
+
+// TokenProfile is a token's listing profile
+type TokenProfile struct {
+	URL          string      `json:"url"`
+	ChainID      string      `json:"chainId"`
+	TokenAddress string      `json:"tokenAddress"`
+	Icon         string      `json:"icon"`
+	Header       string      `json:"header"`
+	Description  string      `json:"description"`
+	Links        []TokenLink `json:"links"`
+}
+
+// TokenBoost is a token's paid boost
+type TokenBoost struct {
+	URL          string      `json:"url"`
+	ChainID      string      `json:"chainId"`
+	TokenAddress string      `json:"tokenAddress"`
+	Amount       float64     `json:"amount"`
+	TotalAmount  float64     `json:"totalAmount"`
+	Icon         string      `json:"icon"`
+	Header       string      `json:"header"`
+	Description  string      `json:"description"`
+	Links        []TokenLink `json:"links"`
+}
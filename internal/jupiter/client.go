@@ -0,0 +1,120 @@
+package jupiter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/soralabs/zen/options"
+)
+
+const (
+	DefaultBaseURL = "https://api.jup.ag"
+
+	// maxErrorBodyLength caps how much of an error response ends up in a StatusError
+	maxErrorBodyLength = 256
+)
+
+// Client is a Jupiter price API client
+type Client struct {
+	options.RequiredFields
+
+	baseURL string
+	timeout time.Duration
+
+	http *resty.Client
+}
+
+// NewClient creates a Jupiter client, by default against the public API
+func NewClient(opts ...options.Option[Client]) (*Client, error) {
+	c := &Client{
+		baseURL: DefaultBaseURL,
+		timeout: 10 * time.Second,
+	}
+
+	if err := options.ApplyOptions(c, opts...); err != nil {
+		return nil, fmt.Errorf("failed to apply options: %w", err)
+	}
+
+	c.http = resty.New().
+		SetBaseURL(c.baseURL).
+		SetTimeout(c.timeout).
+		SetHeader("accept", "application/json")
+
+	return c, nil
+}
+
+// ValidateRequiredFields checks the client configuration
+func (c *Client) ValidateRequiredFields() error {
+	if c.baseURL == "" {
+		return fmt.Errorf("base url is required")
+	}
+	if c.timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	return nil
+}
+
+// WithBaseURL points the client at another Jupiter compatible API
+func WithBaseURL(baseURL string) options.Option[Client] {
+	return func(c *Client) error {
+		c.baseURL = baseURL
+		return nil
+	}
+}
+
+// WithTimeout sets the request timeout
+func WithTimeout(timeout time.Duration) options.Option[Client] {
+	return func(c *Client) error {
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// Prices returns the USD prices of the given mints.
+// Mints Jupiter has no price for are left out of the result.
+func (c *Client) Prices(ctx context.Context, mints ...string) (map[string]Price, error) {
+	response, err := c.http.R().
+		SetContext(ctx).
+		SetQueryParam("ids", strings.Join(mints, ",")).
+		Get("/price/v2")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prices: %w", err)
+	}
+
+	if response.StatusCode() != http.StatusOK {
+		body := response.String()
+		if len(body) > maxErrorBodyLength {
+			body = body[:maxErrorBodyLength]
+		}
+		return nil, &StatusError{StatusCode: response.StatusCode(), Body: body}
+	}
+
+	var priceResponse PriceResponse
+	if err := json.Unmarshal(response.Body(), &priceResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode prices: %w", err)
+	}
+
+	prices := make(map[string]Price, len(priceResponse.Data))
+	for mint, price := range priceResponse.Data {
+		if price != nil {
+			prices[mint] = *price
+		}
+	}
+
+	return prices, nil
+}
+
+// StatusError is returned when Jupiter answers with a status other than 200
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("jupiter returned status %d: %s", e.StatusCode, e.Body)
+}
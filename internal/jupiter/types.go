@@ -0,0 +1,14 @@
+package jupiter
+
+// Price is a token's USD price
+type Price struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Price string `json:"price"`
+}
+
+type PriceResponse struct {
+	// Data is keyed by mint, mints without a price are null
+	Data      map[string]*Price `json:"data"`
+	TimeTaken float64           `json:"timeTaken"`
+}
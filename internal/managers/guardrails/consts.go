@@ -1,6 +1,10 @@
 package guardrails
 
 import (
+	"errors"
+	"time"
+
+	sora_manager "github.com/soralabs/hana/internal/managers/sora"
 	"github.com/soralabs/zen/db"
 	"github.com/soralabs/zen/manager"
 	"github.com/soralabs/zen/state"
@@ -13,6 +17,47 @@ const FragmentTableGuardrails db.FragmentTable = "guardrails"
 
 const GuardrailsResultKey state.StateDataKey = "guardrails_result"
 
+const GuardrailsOutputResultKey state.StateDataKey = "guardrails_output_result"
+
+const InjectionRiskKey state.StateDataKey = "injection_risk"
+
+const (
+	InjectionRiskNone   InjectionRiskLevel = "none"
+	InjectionRiskLow    InjectionRiskLevel = "low"
+	InjectionRiskMedium InjectionRiskLevel = "medium"
+	InjectionRiskHigh   InjectionRiskLevel = "high"
+)
+
+var injectionRiskRank = map[InjectionRiskLevel]int{
+	InjectionRiskNone:   0,
+	InjectionRiskLow:    1,
+	InjectionRiskMedium: 2,
+	InjectionRiskHigh:   3,
+}
+
+const (
+	InjectionSourceTweet  InjectionSource = "tweet"
+	InjectionSourceThread InjectionSource = "thread"
+)
+
+// Fragment types stored in the guardrails table, kept in the "type" metadata key
+const (
+	FragmentTypeInputVerdict  = "input_verdict"
+	FragmentTypeOutputVerdict = "output_verdict"
+	FragmentTypeActorStatus   = "actor_status"
+)
+
+const ActorStatusIgnored = "ignored"
+
+// DefaultStrikePolicy ignores an actor for a day after 3 blocked messages in a day
+// and permanently after 10 blocked messages in total
+var DefaultStrikePolicy = StrikePolicy{
+	TemporaryStrikes:        3,
+	StrikeWindow:            24 * time.Hour,
+	TemporaryIgnoreDuration: 24 * time.Hour,
+	PermanentStrikes:        10,
+}
+
 const (
 	ViolationRacism        ViolationType = "RACISM"
 	ViolationShillOtherCA  ViolationType = "SHILL"
@@ -20,4 +65,21 @@ const (
 	ViolationRevealPrompts ViolationType = "REVEAL_PROMPTS"
 	ViolationSexual        ViolationType = "SEXUAL_CONTENT"
 	ViolationHinting       ViolationType = "HINTING"
+
+	// Output-only violations, checked on the agent's own responses
+	ViolationViolentThreat ViolationType = "VIOLENT_THREAT"
+	ViolationDoxxing       ViolationType = "DOXXING"
+	ViolationPromptLeak    ViolationType = "PROMPT_LEAK"
 )
+
+// ErrOutputBlocked is returned by PostProcess when a generated response violates the guardrails
+var ErrOutputBlocked = errors.New("response blocked by guardrails")
+
+// DefaultShillAllowlist allows Sora's own mint address and ticker
+var DefaultShillAllowlist = ShillAllowlist{
+	Addresses: []string{sora_manager.SoraMintAddress},
+	Cashtags:  []string{"SORA"},
+}
+
+// DefaultAllowedCashtags are the tickers of major coins, talking about them isn't shilling
+var DefaultAllowedCashtags = []string{"SOL", "BTC", "ETH", "USDC", "USDT"}
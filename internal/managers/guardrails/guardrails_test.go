@@ -0,0 +1,76 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/soralabs/hana/internal/testutil"
+	"github.com/soralabs/zen/cache"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/manager"
+	"github.com/soralabs/zen/stores"
+	"gorm.io/gorm"
+)
+
+// newTestGuardrails creates a guardrails manager with the default policy on a temporary sqlite database.
+// There is no LLM unless withModeration sets one, without it only messages the rules block can be checked.
+func newTestGuardrails(t *testing.T, opts ...func(g *GuardrailsManager)) (*GuardrailsManager, *gorm.DB) {
+	t.Helper()
+
+	database := testutil.NewDatabase(t)
+	testutil.CreateFragmentTables(t, database, FragmentTableGuardrails)
+
+	g := &GuardrailsManager{
+		BaseManager: &manager.BaseManager{
+			AssistantName: "hana",
+			AssistantID:   id.FromString("hana"),
+			Ctx:           context.Background(),
+			FragmentStore: stores.NewFragmentStore(context.Background(), database, FragmentTableGuardrails),
+			Cache: cache.New(cache.Config{
+				MaxSize:       100,
+				TTL:           15 * time.Minute,
+				CleanupPeriod: time.Minute,
+			}),
+			Logger: testutil.NewLogger(t),
+		},
+		policy:         &DefaultPolicy,
+		strikePolicy:   DefaultStrikePolicy,
+		shillAllowlist: DefaultShillAllowlist,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g, database
+}
+
+// withModeration gives the manager an LLM that answers every moderation prompt with the verdict,
+// calls counts the prompts
+func withModeration(t *testing.T, verdict moderationResponse, calls *atomic.Int32) func(g *GuardrailsManager) {
+	t.Helper()
+
+	content, err := json.Marshal(verdict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{
+				"index":   0,
+				"message": map[string]string{"role": "assistant", "content": string(content)},
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return func(g *GuardrailsManager) {
+		g.LLM = testutil.NewLLMClient(t, g.Logger, server.URL)
+	}
+}
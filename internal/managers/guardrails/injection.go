@@ -0,0 +1,267 @@
+package guardrails
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	twitter_manager "github.com/soralabs/zen/managers/twitter"
+	"github.com/soralabs/zen/state"
+)
+
+// threadSignalWeight scales signals found in the surrounding thread rather than the tweet itself
+const threadSignalWeight = 0.5
+
+// injectionPattern is a known jailbreak pattern and how much it adds to the risk score
+type injectionPattern struct {
+	name   string
+	regex  *regexp.Regexp
+	weight float64
+}
+
+var injectionPatterns = []injectionPattern{
+	{
+		name:   "ignore_instructions",
+		regex:  regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override|bypass)\b.{0,30}\b(previous|prior|above|earlier|all|your|system)\b.{0,20}\b(instructions?|prompts?|rules|guidelines|directives|context)\b`),
+		weight: 0.6,
+	},
+	{
+		name:   "role_override",
+		regex:  regexp.MustCompile(`(?i)\b(you are now|from now on,? you|act as|pretend (to be|you are)|roleplay as|new persona|developer mode|jailbreak|do anything now|\bDAN\b)`),
+		weight: 0.4,
+	},
+	{
+		name:   "prompt_extraction",
+		regex:  regexp.MustCompile(`(?i)\b(reveal|show|print|repeat|output|tell me|what (is|are))\b.{0,20}\b(your|the)\b.{0,15}\b(system prompt|prompt|instructions|initial message|rules|guidelines)\b`),
+		weight: 0.4,
+	},
+	{
+		name:   "fake_system_message",
+		regex:  regexp.MustCompile(`(?i)(^|\s)(system\s*:|\[system\]|<\|?(im_start|im_end|system)\|?>|###\s*(instruction|system)|</?(system|instructions?|final_answer|contemplator)>)`),
+		weight: 0.5,
+	},
+	{
+		name:   "encoding_request",
+		regex:  regexp.MustCompile(`(?i)\b(decode|base64|rot13|hex[- ]?encoded|reverse the (text|string)|read (it )?backwards)\b`),
+		weight: 0.2,
+	},
+}
+
+var (
+	base64PayloadRegex = regexp.MustCompile(`[A-Za-z0-9+/]{32,}={0,2}`)
+	hexPayloadRegex    = regexp.MustCompile(`\b(?:[0-9a-fA-F]{2}[\s:]?){24,}\b`)
+)
+
+// assessInjection scores the tweet and its thread for known jailbreak patterns
+func assessInjection(tweet, thread string) *InjectionAssessment {
+	assessment := &InjectionAssessment{
+		Level: InjectionRiskNone,
+	}
+
+	assessment.Signals = append(assessment.Signals, injectionSignals(tweet, InjectionSourceTweet)...)
+	if thread != "" {
+		assessment.Signals = append(assessment.Signals, injectionSignals(thread, InjectionSourceThread)...)
+	}
+
+	for _, signal := range assessment.Signals {
+		weight := signal.Weight
+		if signal.Source == InjectionSourceThread {
+			weight *= threadSignalWeight
+		}
+		assessment.Score += weight
+	}
+	assessment.Score = math.Min(1, assessment.Score)
+
+	switch {
+	case assessment.Score >= 0.6:
+		assessment.Level = InjectionRiskHigh
+	case assessment.Score >= 0.3:
+		assessment.Level = InjectionRiskMedium
+	case assessment.Score > 0:
+		assessment.Level = InjectionRiskLow
+	}
+
+	return assessment
+}
+
+// injectionSignals returns the jailbreak signals found in a text
+func injectionSignals(text string, source InjectionSource) []InjectionSignal {
+	var signals []InjectionSignal
+
+	if tricks := unicodeTricks(text); len(tricks) > 0 {
+		signals = append(signals, InjectionSignal{
+			Pattern: "unicode_tricks",
+			Source:  source,
+			Match:   strings.Join(tricks, ", "),
+			Weight:  0.3,
+		})
+	}
+
+	normalized := normalizeText(text)
+
+	for _, pattern := range injectionPatterns {
+		if match := pattern.regex.FindString(normalized); match != "" {
+			signals = append(signals, InjectionSignal{
+				Pattern: pattern.name,
+				Source:  source,
+				Match:   strings.TrimSpace(match),
+				Weight:  pattern.weight,
+			})
+		}
+	}
+
+	if payload, ok := encodedPayload(normalized); ok {
+		signals = append(signals, InjectionSignal{
+			Pattern: "encoded_payload",
+			Source:  source,
+			Match:   payload,
+			Weight:  0.4,
+		})
+	}
+
+	return signals
+}
+
+// unicodeTricks lists the kinds of invisible or deceptive characters in a text
+func unicodeTricks(text string) []string {
+	found := make(map[string]bool)
+	var tricks []string
+	add := func(trick string) {
+		if !found[trick] {
+			found[trick] = true
+			tricks = append(tricks, trick)
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case r >= 0x200B && r <= 0x200F, r == 0x2060, r == 0xFEFF, r == 0x00AD:
+			add("zero_width")
+		case r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069:
+			add("bidi_override")
+		case r >= 0xE0000 && r <= 0xE007F:
+			add("tag_characters")
+		case r >= 0xFF01 && r <= 0xFF5E:
+			add("fullwidth")
+		}
+	}
+
+	// lookalike letters from other scripts inside a latin word sneak past keyword filters
+	for _, word := range strings.Fields(text) {
+		hasLatin, hasLookalike := false, false
+		for _, r := range word {
+			switch {
+			case unicode.Is(unicode.Latin, r):
+				hasLatin = true
+			case unicode.Is(unicode.Cyrillic, r), unicode.Is(unicode.Greek, r):
+				hasLookalike = true
+			}
+		}
+		if hasLatin && hasLookalike {
+			add("mixed_scripts")
+			break
+		}
+	}
+
+	return tricks
+}
+
+// normalizeText strips invisible characters and folds fullwidth letters so patterns still match
+func normalizeText(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 0x200B && r <= 0x200F, r == 0x2060, r == 0xFEFF, r == 0x00AD,
+			r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069,
+			r >= 0xE0000 && r <= 0xE007F:
+			return -1
+		case r >= 0xFF01 && r <= 0xFF5E:
+			return r - 0xFEE0
+		}
+		return r
+	}, text)
+}
+
+// encodedPayload finds base64 or hex blobs that decode to readable text
+func encodedPayload(text string) (string, bool) {
+	for _, candidate := range base64PayloadRegex.FindAllString(text, -1) {
+		// base58 addresses also match but don't decode to readable text
+		decoded, err := base64.StdEncoding.DecodeString(padBase64(candidate))
+		if err == nil && isReadable(decoded) {
+			return candidate, true
+		}
+	}
+
+	for _, candidate := range hexPayloadRegex.FindAllString(text, -1) {
+		// transaction hashes and addresses are hex too but don't decode to readable text
+		decoded, err := hex.DecodeString(strings.NewReplacer(" ", "", ":", "", "\n", "", "\t", "").Replace(candidate))
+		if err == nil && isReadable(decoded) {
+			return candidate, true
+		}
+	}
+
+	return "", false
+}
+
+func padBase64(candidate string) string {
+	candidate = strings.TrimRight(candidate, "=")
+	if missing := len(candidate) % 4; missing != 0 {
+		candidate += strings.Repeat("=", 4-missing)
+	}
+	return candidate
+}
+
+// isReadable reports whether decoded bytes are mostly printable ASCII text
+func isReadable(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	printable := 0
+	for _, b := range data {
+		if b == ' ' || b == '\n' || (b >= 0x21 && b <= 0x7E) {
+			printable++
+		}
+	}
+
+	return float64(printable)/float64(len(data)) > 0.9
+}
+
+// AssessInjection scores the input tweet and the thread around it for prompt injection
+// and jailbreak attempts. The assessment is added to the state under InjectionRiskKey so
+// reply generation can switch to a hardened prompt. It runs locally without the LLM.
+// Call it after the state has been updated with the twitter conversation.
+func (g *GuardrailsManager) AssessInjection(currentState *state.State) (*InjectionAssessment, error) {
+	if currentState.Input == nil {
+		return nil, fmt.Errorf("input is required")
+	}
+
+	thread := ""
+	if conversations, exists := currentState.GetManagerData(twitter_manager.TwitterConversations); exists {
+		// the tweet itself is scored on its own
+		thread = strings.ReplaceAll(fmt.Sprintf("%v", conversations), currentState.Input.Content, "")
+	}
+
+	assessment := assessInjection(currentState.Input.Content, thread)
+
+	if assessment.Level != InjectionRiskNone {
+		g.Logger.WithFields(map[string]interface{}{
+			"fragment_id": currentState.Input.ID,
+			"score":       assessment.Score,
+			"level":       assessment.Level,
+			"signals":     assessment.Signals,
+		}).Warnf("Prompt injection signals detected")
+	}
+
+	currentState.AddManagerData([]state.StateData{
+		{
+			Key:   InjectionRiskKey,
+			Value: assessment,
+		},
+	})
+
+	return assessment, nil
+}
@@ -0,0 +1,79 @@
+package guardrails
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/soralabs/zen/options"
+)
+
+// ValidateRequiredFields checks if all required fields are set
+func (g *GuardrailsManager) ValidateRequiredFields() error {
+	if err := g.BaseManager.ValidateRequiredFields(); err != nil {
+		return err
+	}
+	if g.policy == nil {
+		return fmt.Errorf("policy is required")
+	}
+	if err := g.policy.Validate(); err != nil {
+		return fmt.Errorf("invalid policy: %w", err)
+	}
+	if g.strikePolicy.TemporaryStrikes <= 0 {
+		return fmt.Errorf("strike policy requires a positive number of temporary strikes")
+	}
+	return nil
+}
+
+// WithPolicy sets the moderation policy, replacing the default one
+func WithPolicy(policy *Policy) options.Option[GuardrailsManager] {
+	return func(g *GuardrailsManager) error {
+		g.policy = policy
+		return nil
+	}
+}
+
+// WithStrikePolicy sets the policy used to escalate repeat offenders to the ignore list
+func WithStrikePolicy(policy StrikePolicy) options.Option[GuardrailsManager] {
+	return func(g *GuardrailsManager) error {
+		g.strikePolicy = policy
+		return nil
+	}
+}
+
+// WithShillAllowlist adds addresses and cashtags that the shill rules should allow
+func WithShillAllowlist(allowlist ShillAllowlist) options.Option[GuardrailsManager] {
+	return func(g *GuardrailsManager) error {
+		g.shillAllowlist.Addresses = append(g.shillAllowlist.Addresses, allowlist.Addresses...)
+		g.shillAllowlist.Cashtags = append(g.shillAllowlist.Cashtags, allowlist.Cashtags...)
+		return nil
+	}
+}
+
+// WithWalletResolver lets incoming messages contain wallet addresses without counting as shilling.
+// Responses are still checked against every address.
+func WithWalletResolver(resolver WalletResolver) options.Option[GuardrailsManager] {
+	return func(g *GuardrailsManager) error {
+		g.walletResolver = resolver
+		return nil
+	}
+}
+
+// tickerRegex matches a ticker with or without its leading $
+var tickerRegex = regexp.MustCompile(`^\$?[A-Za-z][A-Za-z0-9]{0,9}$`)
+
+// ParseCashtags parses a comma separated list of tickers, with or without their leading $
+func ParseCashtags(value string) ([]string, error) {
+	var cashtags []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !tickerRegex.MatchString(entry) {
+			return nil, fmt.Errorf("invalid cashtag %q", entry)
+		}
+		cashtags = append(cashtags, strings.TrimPrefix(entry, "$"))
+	}
+	return cashtags, nil
+}
@@ -0,0 +1,109 @@
+package guardrails
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/soralabs/zen/cache"
+	"github.com/soralabs/zen/db"
+)
+
+// promptLeakMarkers are fragments of the agent's prompts that should never appear in a response
+var promptLeakMarkers = []string{
+	"<contemplator>",
+	"</contemplator>",
+	"<final_answer>",
+	"<thought_process>",
+	"core principles:",
+	"personality-driven exploration",
+	"authentic thinking process",
+	"twitter requirements:",
+	"tweet guidelines:",
+	"available context:",
+	"your response must follow this structure",
+}
+
+var (
+	emailRegex = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phoneRegex = regexp.MustCompile(`\(?\d{3}\)?[\s.-]\d{3}[\s.-]\d{4}`)
+)
+
+// checkOutput moderates a generated response.
+// Verdicts are cached by fragment ID and content so the same response is only checked once
+// even when it goes through post-processing more than once.
+func (g *GuardrailsManager) checkOutput(response *db.Fragment) (*ContentModerationResult, error) {
+	cacheKey := outputCacheKey(response)
+	if cached, exists := g.Cache.Get(cacheKey); exists {
+		return cached.(*ContentModerationResult), nil
+	}
+
+	reported := g.checkOutputRules(response.Content)
+	rawOutput := ""
+	result := g.evaluate(ScopeOutput, response, reported)
+	if result.Allowed {
+		llmReported, raw, err := g.moderateOutput(response.Content)
+		if err != nil {
+			return nil, err
+		}
+		rawOutput = raw
+		result = g.evaluate(ScopeOutput, response, append(reported, llmReported...))
+	}
+
+	if !result.Allowed {
+		g.Logger.WithFields(map[string]interface{}{
+			"fragment_id": response.ID,
+			"content":     response.Content,
+			"reasons":     result.Reasons(),
+		}).Warnf("Response blocked by guardrails")
+	}
+
+	g.Cache.Set(cacheKey, result)
+
+	if err := g.recordVerdict(FragmentTypeOutputVerdict, response, result, rawOutput); err != nil {
+		g.Logger.Warnf("failed to record output verdict for %s: %v", response.ID, err)
+	}
+
+	return result, nil
+}
+
+// outputCacheKey is the cache key of a response's verdict.
+// It includes a hash of the content, so a response edited after it was checked is checked again.
+func outputCacheKey(response *db.Fragment) cache.CacheKey {
+	sum := sha256.Sum256([]byte(response.Content))
+	return cache.CacheKey(fmt.Sprintf("%s_%s_%x", GuardrailsOutputResultKey, response.ID, sum[:8]))
+}
+
+// checkOutputRules runs the deterministic output checks that don't need the LLM
+func (g *GuardrailsManager) checkOutputRules(content string) []ViolationType {
+	reported := violationTypes(g.checkShillRules(content))
+
+	lowered := strings.ToLower(content)
+	for _, marker := range promptLeakMarkers {
+		if strings.Contains(lowered, marker) {
+			reported = append(reported, ViolationPromptLeak)
+			break
+		}
+	}
+
+	if emailRegex.MatchString(content) || phoneRegex.MatchString(content) {
+		reported = append(reported, ViolationDoxxing)
+	}
+
+	return reported
+}
+
+// moderateOutput uses the LLM to check a response for the policy's output categories.
+// The reported violation types are returned alongside the raw model output.
+func (g *GuardrailsManager) moderateOutput(content string) ([]ViolationType, string, error) {
+	prompt := `Analyze the following response before it is posted publicly. It was written by an AI agent whose persona is intentionally rough, rude and dramatic.
+In-character teasing, insults and obviously fictional or exaggerated aggression are acceptable. The response must not contain:
+` + g.policy.promptSection(ScopeOutput) + `
+
+Only include reasons if violations are found. Response to analyze:
+
+` + content
+
+	return g.moderate(prompt)
+}
@@ -0,0 +1,41 @@
+package guardrails
+
+import (
+	"testing"
+
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
+)
+
+func TestCheckOutputScreensEditedResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		edited string
+		want   ViolationType
+	}{
+		{name: "cashtag", edited: "gm, $PEPE is the only play today", want: ViolationShillOtherCA},
+		{name: "email", edited: "gm, email me at hana@example.com for alpha", want: ViolationDoxxing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _ := newTestGuardrails(t)
+
+			// the draft was allowed when it was generated, an approver then edits it under the same ID
+			draft := &db.Fragment{ID: id.New(), Content: "gm, the candles are sleepy today"}
+			g.Cache.Set(outputCacheKey(draft), &ContentModerationResult{Allowed: true})
+			edited := &db.Fragment{ID: draft.ID, Content: tt.edited}
+
+			result, err := g.checkOutput(edited)
+			if err != nil {
+				t.Fatalf("checkOutput: %v", err)
+			}
+			if result.Allowed {
+				t.Fatalf("edit %q was allowed with the draft's verdict", tt.edited)
+			}
+			if reasons := result.Reasons(); len(reasons) == 0 || reasons[0] != string(tt.want) {
+				t.Errorf("got reasons %v, want %s", reasons, tt.want)
+			}
+		})
+	}
+}
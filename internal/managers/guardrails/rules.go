@@ -0,0 +1,154 @@
+package guardrails
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+var (
+	solanaAddressRegex = regexp.MustCompile(`\b[1-9A-HJ-NP-Za-km-z]{32,44}\b`)
+	evmAddressRegex    = regexp.MustCompile(`\b0x[0-9a-fA-F]{40}\b`)
+	cashtagRegex       = regexp.MustCompile(`\$([A-Za-z][A-Za-z0-9]{0,9})\b`)
+	urlRegex           = regexp.MustCompile(`(?i)\b(?:https?://)?(?:[a-z0-9-]+\.)*(?:pump\.fun|dexscreener\.com|birdeye\.so)(?:/[^\s]*)?`)
+)
+
+// ShillAllowlist holds the addresses and cashtags that never count as shilling
+type ShillAllowlist struct {
+	// Addresses are Solana or EVM addresses, EVM addresses are matched case-insensitively
+	Addresses []string
+	// Cashtags are tickers without the leading $, matched case-insensitively
+	Cashtags []string
+}
+
+// RuleMatch is a single hit of a deterministic rule
+type RuleMatch struct {
+	Violation ViolationType
+	Rule      string
+	Match     string
+}
+
+// checkShillRules looks for contract addresses, token links and cashtags that aren't allowlisted.
+// No matches means the rules are inconclusive and the LLM decides.
+func (g *GuardrailsManager) checkShillRules(content string) []RuleMatch {
+	var matches []RuleMatch
+
+	for _, link := range urlRegex.FindAllString(content, -1) {
+		if g.isAllowedLink(link) {
+			continue
+		}
+		matches = append(matches, RuleMatch{Violation: ViolationShillOtherCA, Rule: "token_link", Match: link})
+	}
+
+	// addresses inside links were handled above
+	stripped := urlRegex.ReplaceAllString(content, " ")
+
+	for _, address := range evmAddressRegex.FindAllString(stripped, -1) {
+		if g.isAllowedAddress(address) {
+			continue
+		}
+		matches = append(matches, RuleMatch{Violation: ViolationShillOtherCA, Rule: "evm_address", Match: address})
+	}
+
+	for _, candidate := range solanaAddressRegex.FindAllString(stripped, -1) {
+		if !isSolanaAddress(candidate) || g.isAllowedAddress(candidate) {
+			continue
+		}
+		matches = append(matches, RuleMatch{Violation: ViolationShillOtherCA, Rule: "solana_address", Match: candidate})
+	}
+
+	for _, match := range cashtagRegex.FindAllStringSubmatch(stripped, -1) {
+		if g.isAllowedCashtag(match[1]) {
+			continue
+		}
+		matches = append(matches, RuleMatch{Violation: ViolationShillOtherCA, Rule: "cashtag", Match: match[0]})
+	}
+
+	return matches
+}
+
+// checkInputShillRules runs the shill rules on an incoming message, Solana addresses that
+// resolve to wallets are returned separately instead of as matches.
+// Only the first addresses are resolved, the ones past the lookup limit count as any other address.
+// Cashtags alone are inconclusive, tickers are often only mentioned in passing, so they are
+// only returned next to another match and the LLM decides otherwise.
+func (g *GuardrailsManager) checkInputShillRules(content string) (matches []RuleMatch, wallets []string) {
+	if g.walletResolver != nil {
+		// addresses inside links are never wallets to look up
+		wallets = g.walletResolver.FindWallets(urlRegex.ReplaceAllString(content, " "))
+	}
+
+	for _, match := range g.checkShillRules(content) {
+		if match.Rule == "solana_address" && slices.Contains(wallets, match.Match) {
+			continue
+		}
+		matches = append(matches, match)
+	}
+
+	if !slices.ContainsFunc(matches, func(match RuleMatch) bool { return match.Rule != "cashtag" }) {
+		return nil, wallets
+	}
+	return matches, wallets
+}
+
+// isAllowedLink allows token links that point at an allowlisted address
+func (g *GuardrailsManager) isAllowedLink(link string) bool {
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	for _, segment := range strings.Split(parsed.Path, "/") {
+		if segment != "" && g.isAllowedAddress(segment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAllowedAddress checks an address against the allowlist
+func (g *GuardrailsManager) isAllowedAddress(address string) bool {
+	for _, allowed := range g.shillAllowlist.Addresses {
+		if address == allowed {
+			return true
+		}
+		if strings.HasPrefix(allowed, "0x") && strings.EqualFold(address, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAllowedCashtag checks a ticker against the allowlist
+func (g *GuardrailsManager) isAllowedCashtag(ticker string) bool {
+	for _, allowed := range g.shillAllowlist.Cashtags {
+		if strings.EqualFold(strings.TrimPrefix(allowed, "$"), ticker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSolanaAddress reports whether the string decodes to a 32 byte public key.
+// This filters out long base58-looking words that aren't addresses.
+func isSolanaAddress(candidate string) bool {
+	_, err := solana.PublicKeyFromBase58(candidate)
+	return err == nil
+}
+
+// violationTypes returns the violation types of rule matches
+func violationTypes(matches []RuleMatch) []ViolationType {
+	types := make([]ViolationType, 0, len(matches))
+	for _, match := range matches {
+		types = append(types, match.Violation)
+	}
+	return types
+}
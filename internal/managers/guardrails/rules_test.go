@@ -0,0 +1,218 @@
+package guardrails
+
+import (
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	sora_manager "github.com/soralabs/hana/internal/managers/sora"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/state"
+)
+
+func TestCheckShillRules(t *testing.T) {
+	otherMint := solana.NewWallet().PublicKey().String()
+	const evmAddress = "0x6B175474E89094C44Da98b954EedeAC495271d0F"
+
+	tests := []struct {
+		name      string
+		content   string
+		allowlist *ShillAllowlist
+		want      []RuleMatch
+	}{
+		{
+			name:    "no token",
+			content: "gm hana, how are the candles today?",
+		},
+		{
+			name:    "sora mint",
+			content: "aped into " + sora_manager.SoraMintAddress,
+		},
+		{
+			name:    "other mint",
+			content: "aped into " + otherMint,
+			want:    []RuleMatch{{Violation: ViolationShillOtherCA, Rule: "solana_address", Match: otherMint}},
+		},
+		{
+			name:    "base58 word that isn't a key",
+			content: "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQ",
+		},
+		{
+			name:    "evm address",
+			content: "bridge to " + evmAddress,
+			want:    []RuleMatch{{Violation: ViolationShillOtherCA, Rule: "evm_address", Match: evmAddress}},
+		},
+		{
+			name:      "allowlisted evm address in another case",
+			content:   "bridge to " + evmAddress,
+			allowlist: &ShillAllowlist{Addresses: []string{"0x6b175474e89094c44da98b954eedeac495271d0f"}},
+		},
+		{
+			name:    "token link",
+			content: "chart is wild https://dexscreener.com/solana/" + otherMint,
+			want: []RuleMatch{{
+				Violation: ViolationShillOtherCA,
+				Rule:      "token_link",
+				Match:     "https://dexscreener.com/solana/" + otherMint,
+			}},
+		},
+		{
+			name:    "token link without a scheme",
+			content: "launching on pump.fun/coin/" + otherMint + " tonight",
+			want: []RuleMatch{{
+				Violation: ViolationShillOtherCA,
+				Rule:      "token_link",
+				Match:     "pump.fun/coin/" + otherMint,
+			}},
+		},
+		{
+			name:    "link to the sora mint",
+			content: "https://birdeye.so/token/" + sora_manager.SoraMintAddress,
+		},
+		{
+			name:    "other cashtag",
+			content: "$PEPE is the only play",
+			want:    []RuleMatch{{Violation: ViolationShillOtherCA, Rule: "cashtag", Match: "$PEPE"}},
+		},
+		{
+			name:    "sora cashtag in lowercase",
+			content: "$sora to the moon",
+		},
+		{
+			name:    "sol cashtag",
+			content: "$SOL looks strong",
+			want:    []RuleMatch{{Violation: ViolationShillOtherCA, Rule: "cashtag", Match: "$SOL"}},
+		},
+		{
+			name:    "btc cashtag",
+			content: "$BTC dominance is up",
+			want:    []RuleMatch{{Violation: ViolationShillOtherCA, Rule: "cashtag", Match: "$BTC"}},
+		},
+		{
+			name:      "default allowed cashtags",
+			content:   "$SOL, $ETH and $btc look strong",
+			allowlist: &ShillAllowlist{Cashtags: DefaultAllowedCashtags},
+		},
+		{
+			name:      "allowlisted cashtags",
+			content:   "$SOL and $btc look strong",
+			allowlist: &ShillAllowlist{Cashtags: []string{"SORA", "$SOL", "BTC"}},
+		},
+		{
+			name:    "price",
+			content: "sora at $5 soon",
+		},
+		{
+			name:    "every match",
+			content: "$PEPE " + otherMint + " " + evmAddress + " pump.fun/coin/abc",
+			want: []RuleMatch{
+				{Violation: ViolationShillOtherCA, Rule: "token_link", Match: "pump.fun/coin/abc"},
+				{Violation: ViolationShillOtherCA, Rule: "evm_address", Match: evmAddress},
+				{Violation: ViolationShillOtherCA, Rule: "solana_address", Match: otherMint},
+				{Violation: ViolationShillOtherCA, Rule: "cashtag", Match: "$PEPE"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _ := newTestGuardrails(t, func(g *GuardrailsManager) {
+				if tt.allowlist != nil {
+					g.shillAllowlist = *tt.allowlist
+				}
+			})
+
+			if got := g.checkShillRules(tt.content); !slices.Equal(got, tt.want) {
+				t.Errorf("got matches %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCashtagAloneIsLeftToTheLLM pins down that a cashtag that isn't allowlisted doesn't block a message
+// on its own, the LLM decides whether it is shilled and only its verdict earns a strike
+func TestCashtagAloneIsLeftToTheLLM(t *testing.T) {
+	otherMint := solana.NewWallet().PublicKey().String()
+
+	tests := []struct {
+		name        string
+		content     string
+		verdict     moderationResponse
+		wantAllowed bool
+		// wantLLM is whether the message reaches the LLM
+		wantLLM bool
+	}{
+		{
+			name:        "cashtag the LLM allows",
+			content:     "what do you think about $PEPE?",
+			verdict:     moderationResponse{Allowed: true},
+			wantAllowed: true,
+			wantLLM:     true,
+		},
+		{
+			name:    "cashtag the LLM finds shilled",
+			content: "$PEPE is sending, buy now",
+			verdict: moderationResponse{Reasons: []ViolationType{ViolationShillOtherCA}},
+			wantLLM: true,
+		},
+		{
+			name:    "cashtag next to a contract address",
+			content: "$PEPE is sending " + otherMint,
+			verdict: moderationResponse{Allowed: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			g, database := newTestGuardrails(t, withModeration(t, tt.verdict, &calls))
+
+			input := &db.Fragment{
+				ID:        id.New(),
+				ActorID:   id.New(),
+				SessionID: id.New(),
+				Content:   tt.content,
+				Metadata:  db.Metadata{"tweet_id": "1"},
+			}
+			currentState := &state.State{Input: input}
+			if err := g.Process(currentState); err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+
+			if reached := calls.Load() > 0; reached != tt.wantLLM {
+				t.Errorf("got the LLM asked %v, want %v", reached, tt.wantLLM)
+			}
+
+			value, exists := currentState.GetManagerData(GuardrailsResultKey)
+			if !exists {
+				t.Fatal("no guardrails result")
+			}
+			result := value.(*ContentModerationResult)
+			if result.Allowed != tt.wantAllowed {
+				t.Fatalf("got allowed %v, want %v", result.Allowed, tt.wantAllowed)
+			}
+			if !tt.wantAllowed {
+				if reply, ok := g.CannedReply(result); result.Action != ActionReply || !ok || reply == "" {
+					t.Errorf("got action %s without a canned reply, want a reply", result.Action)
+				}
+			}
+
+			var strikes int64
+			if err := database.Table(string(FragmentTableGuardrails)).
+				Where("actor_id = ? AND json_extract(metadata, '$.type') = ? AND json_extract(metadata, '$.allowed') = 0",
+					input.ActorID, FragmentTypeInputVerdict).
+				Count(&strikes).Error; err != nil {
+				t.Fatalf("failed to count strikes: %v", err)
+			}
+			wantStrikes := int64(1)
+			if tt.wantAllowed {
+				wantStrikes = 0
+			}
+			if strikes != wantStrikes {
+				t.Errorf("got %d strikes, want %d", strikes, wantStrikes)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package guardrails
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/soralabs/zen/cache"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/stores"
+)
+
+// recordVerdict stores a moderation verdict for the given fragment in the guardrails table.
+// The verdict keeps the fragment's actor and session so it can be looked up per actor later.
+func (g *GuardrailsManager) recordVerdict(verdictType string, fragment *db.Fragment, result *ContentModerationResult, rawOutput string) error {
+	tweetID := fragment.Metadata.GetString("tweet_id")
+	if verdictType == FragmentTypeOutputVerdict {
+		tweetID = fragment.Metadata.GetString("in_reply_to_tweet_id")
+	}
+
+	return g.Store(&db.Fragment{
+		ID:        id.New(),
+		ActorID:   fragment.ActorID,
+		SessionID: fragment.SessionID,
+		Content:   fragment.Content,
+		Embedding: fragment.Embedding,
+		Metadata: db.Metadata{
+			"type":        verdictType,
+			"fragment_id": fragment.ID.String(),
+			"tweet_id":    tweetID,
+			"allowed":     result.Allowed,
+			"reasons":     result.Reasons(),
+			"violations":  result.Violations,
+			"action":      result.Action,
+			"raw_output":  rawOutput,
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+}
+
+// addStrike counts the actor's blocked messages after a new violation and
+// escalates them to the ignore list when the strike policy is exceeded
+func (g *GuardrailsManager) addStrike(fragment *db.Fragment) error {
+	status, err := g.GetActorStatus(fragment.ActorID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	totalStrikes := 0
+	if g.strikePolicy.PermanentStrikes > 0 {
+		totalStrikes, err = g.countStrikes(fragment.ActorID, time.Time{})
+		if err != nil {
+			return err
+		}
+	}
+
+	// strikes that led to a previous ignore don't count towards the next one
+	windowStart := now.Add(-g.strikePolicy.StrikeWindow)
+	if status.Since.After(windowStart) {
+		windowStart = status.Since
+	}
+	windowStrikes, err := g.countStrikes(fragment.ActorID, windowStart)
+	if err != nil {
+		return err
+	}
+
+	g.Logger.WithFields(map[string]interface{}{
+		"actor_id":       fragment.ActorID,
+		"window_strikes": windowStrikes,
+		"total_strikes":  totalStrikes,
+	}).Infof("Added guardrails strike")
+
+	switch {
+	case g.strikePolicy.PermanentStrikes > 0 && totalStrikes >= g.strikePolicy.PermanentStrikes:
+		return g.ignoreActor(fragment, time.Time{}, totalStrikes)
+	case windowStrikes >= g.strikePolicy.TemporaryStrikes:
+		return g.ignoreActor(fragment, now.Add(g.strikePolicy.TemporaryIgnoreDuration), windowStrikes)
+	}
+
+	return nil
+}
+
+// countStrikes returns the number of blocked input verdicts for the actor since the given time.
+// A zero time counts all strikes.
+func (g *GuardrailsManager) countStrikes(actorID id.ID, since time.Time) (int, error) {
+	filter := stores.FragmentFilter{
+		ActorID: &actorID,
+		Metadata: []stores.MetadataCondition{
+			{Key: "type", Value: FragmentTypeInputVerdict, Operator: stores.MetadataOpEquals},
+			{Key: "allowed", Value: false, Operator: stores.MetadataOpEquals},
+		},
+	}
+	if !since.IsZero() {
+		filter.StartTime = &since
+	}
+
+	verdicts, err := g.FragmentStore.SearchByFilter(filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count strikes: %w", err)
+	}
+
+	return len(verdicts), nil
+}
+
+// ignoreActor records an ignore status for the actor.
+// A zero until time ignores the actor permanently.
+func (g *GuardrailsManager) ignoreActor(fragment *db.Fragment, until time.Time, strikes int) error {
+	now := time.Now()
+
+	var ignoredUntil int64
+	if !until.IsZero() {
+		ignoredUntil = until.Unix()
+	}
+
+	if err := g.Store(&db.Fragment{
+		ID:        id.New(),
+		ActorID:   fragment.ActorID,
+		SessionID: fragment.SessionID,
+		Content:   fmt.Sprintf("actor ignored after %d strikes", strikes),
+		Embedding: pgvector.NewVector(make([]float32, 1536)),
+		Metadata: db.Metadata{
+			"type":          FragmentTypeActorStatus,
+			"status":        ActorStatusIgnored,
+			"ignored_until": ignoredUntil,
+			"strikes":       strikes,
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		return fmt.Errorf("failed to store actor status: %w", err)
+	}
+
+	g.Cache.Set(actorStatusCacheKey(fragment.ActorID), &ActorStatus{
+		Ignored: true,
+		Until:   until,
+		Since:   now,
+	})
+
+	g.Logger.WithFields(map[string]interface{}{
+		"actor_id":  fragment.ActorID,
+		"strikes":   strikes,
+		"permanent": until.IsZero(),
+		"until":     until,
+	}).Warnf("Actor added to ignore list")
+
+	return nil
+}
+
+// GetActorStatus returns the latest ignore status recorded for the actor
+func (g *GuardrailsManager) GetActorStatus(actorID id.ID) (*ActorStatus, error) {
+	cacheKey := actorStatusCacheKey(actorID)
+	if cached, exists := g.Cache.Get(cacheKey); exists {
+		return cached.(*ActorStatus), nil
+	}
+
+	statuses, err := g.FragmentStore.SearchByFilter(stores.FragmentFilter{
+		ActorID: &actorID,
+		Metadata: []stores.MetadataCondition{
+			{Key: "type", Value: FragmentTypeActorStatus, Operator: stores.MetadataOpEquals},
+		},
+		Limit: 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get actor status: %w", err)
+	}
+
+	status := &ActorStatus{}
+	if len(statuses) > 0 {
+		latest := statuses[0]
+		status.Ignored = latest.Metadata.GetString("status") == ActorStatusIgnored
+		status.Since = latest.CreatedAt
+		if until := int64(latest.Metadata.GetFloat("ignored_until")); until > 0 {
+			status.Until = time.Unix(until, 0)
+		}
+	}
+
+	g.Cache.Set(cacheKey, status)
+
+	return status, nil
+}
+
+// IsActorIgnored reports whether the actor is currently on the ignore list.
+// It only reads stored statuses, so it is cheap enough to call before any LLM work.
+func (g *GuardrailsManager) IsActorIgnored(actorID id.ID) (bool, error) {
+	status, err := g.GetActorStatus(actorID)
+	if err != nil {
+		return false, err
+	}
+
+	return status.IsActive(time.Now()), nil
+}
+
+func actorStatusCacheKey(actorID id.ID) cache.CacheKey {
+	return cache.CacheKey(fmt.Sprintf("guardrails_actor_status_%s", actorID))
+}
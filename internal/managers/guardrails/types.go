@@ -1,6 +1,8 @@
 package guardrails
 
 import (
+	"time"
+
 	"github.com/soralabs/zen/manager"
 	"github.com/soralabs/zen/options"
 )
@@ -9,13 +11,104 @@ import (
 type GuardrailsManager struct {
 	*manager.BaseManager
 	options.RequiredFields
+
+	policy         *Policy
+	strikePolicy   StrikePolicy
+	shillAllowlist ShillAllowlist
+	walletResolver WalletResolver
+}
+
+// WalletResolver tells wallet addresses apart from token and program addresses
+type WalletResolver interface {
+	// FindWallets returns the wallets among the first sora_manager.MaxWalletLookups addresses of a text
+	FindWallets(content string) []string
 }
 
 // ContentModerationResult represents the result of content moderation
 type ContentModerationResult struct {
-	Allowed bool     `json:"allowed"`
-	Reasons []string `json:"reasons,omitempty"`
+	Allowed    bool        `json:"allowed"`
+	Violations []Violation `json:"violations,omitempty"`
+	// Action is the strictest action of the violations, empty when there are none
+	Action PolicyAction `json:"action,omitempty"`
+}
+
+// Violation is a policy category a message was found to violate
+type Violation struct {
+	Type     ViolationType `json:"type"`
+	Severity Severity      `json:"severity"`
+	Action   PolicyAction  `json:"action"`
+}
+
+// moderationResponse is the JSON format the moderation prompts ask the LLM for
+type moderationResponse struct {
+	Allowed bool            `json:"allowed"`
+	Reasons []ViolationType `json:"reasons,omitempty"`
+}
+
+// Reasons returns the violation types as strings
+func (r ContentModerationResult) Reasons() []string {
+	reasons := make([]string, 0, len(r.Violations))
+	for _, violation := range r.Violations {
+		reasons = append(reasons, string(violation.Type))
+	}
+	return reasons
 }
 
 // ViolationType represents different types of content violations
 type ViolationType string
+
+// StrikePolicy configures when blocked messages escalate an actor to the ignore list.
+// Strikes are counted from blocked input verdicts recorded in the guardrails table.
+type StrikePolicy struct {
+	// TemporaryStrikes within StrikeWindow ignore the actor for TemporaryIgnoreDuration
+	TemporaryStrikes        int
+	StrikeWindow            time.Duration
+	TemporaryIgnoreDuration time.Duration
+
+	// PermanentStrikes in total ignore the actor permanently, 0 disables permanent ignores
+	PermanentStrikes int
+}
+
+// ActorStatus is the current ignore status of an actor
+type ActorStatus struct {
+	Ignored bool
+	// Until is when a temporary ignore expires, zero for permanent ignores
+	Until time.Time
+	// Since is when the status was set
+	Since time.Time
+}
+
+// IsActive reports whether the ignore is in effect at the given time
+func (s ActorStatus) IsActive(now time.Time) bool {
+	if !s.Ignored {
+		return false
+	}
+	return s.Until.IsZero() || now.Before(s.Until)
+}
+
+// InjectionRiskLevel buckets the prompt injection risk score
+type InjectionRiskLevel string
+
+// InjectionSource is where an injection signal was found
+type InjectionSource string
+
+// InjectionAssessment is the result of the prompt injection stage
+type InjectionAssessment struct {
+	// Score is between 0 and 1
+	Score   float64            `json:"score"`
+	Level   InjectionRiskLevel `json:"level"`
+	Signals []InjectionSignal  `json:"signals,omitempty"`
+}
+
+// InjectionSignal is a single jailbreak pattern found in the tweet or its thread
+type InjectionSignal struct {
+	Pattern string          `json:"pattern"`
+	Source  InjectionSource `json:"source"`
+	Match   string          `json:"match"`
+	Weight  float64         `json:"weight"`
+}
+
+// AtLeast reports whether the assessed risk is at or above the given level
+func (a InjectionAssessment) AtLeast(level InjectionRiskLevel) bool {
+	return injectionRiskRank[a.Level] >= injectionRiskRank[level]
+}
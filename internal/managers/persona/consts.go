@@ -0,0 +1,6 @@
+package persona
+
+import "github.com/soralabs/zen/state"
+
+// PersonaVersion is the key for the version of the persona the personality in the state comes from
+const PersonaVersion state.StateDataKey = "persona_version"
@@ -0,0 +1,107 @@
+package persona
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/soralabs/zen/manager"
+	"github.com/soralabs/zen/managers/personality"
+	"github.com/soralabs/zen/options"
+	"github.com/soralabs/zen/state"
+)
+
+// PersonaManager is the personality manager of an agent whose persona comes from a persona file.
+// The persona can be replaced while the agent runs, prompts built afterwards use the new one.
+type PersonaManager struct {
+	*personality.PersonalityManager
+	options.RequiredFields
+
+	baseOpts []options.Option[manager.BaseManager]
+
+	mu      sync.RWMutex
+	persona *Persona
+	// current is the personality manager of the current persona, it formats the personality for the prompts
+	current *personality.PersonalityManager
+}
+
+func NewPersonaManager(
+	baseOpts []options.Option[manager.BaseManager],
+	personaOpts ...options.Option[PersonaManager],
+) (*PersonaManager, error) {
+	pm := &PersonaManager{
+		baseOpts: baseOpts,
+	}
+
+	if err := options.ApplyOptions(pm, personaOpts...); err != nil {
+		return nil, err
+	}
+
+	current, err := pm.newPersonalityManager(pm.persona)
+	if err != nil {
+		return nil, err
+	}
+	pm.PersonalityManager = current
+	pm.current = current
+
+	return pm, nil
+}
+
+// Context returns the personality of the current persona along with its version,
+// so a response records the version its prompt was built with
+func (pm *PersonaManager) Context(currentState *state.State) ([]state.StateData, error) {
+	pm.mu.RLock()
+	current := pm.current
+	version := pm.persona.Version
+	pm.mu.RUnlock()
+
+	data, err := current.Context(currentState)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, state.StateData{
+		Key:   PersonaVersion,
+		Value: version,
+	}), nil
+}
+
+// Persona returns the current persona
+func (pm *PersonaManager) Persona() *Persona {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	return pm.persona
+}
+
+// SetPersona validates a persona and replaces the current one with it.
+// An invalid persona is rejected and the current one stays.
+func (pm *PersonaManager) SetPersona(persona *Persona) error {
+	if err := persona.Validate(); err != nil {
+		return fmt.Errorf("invalid persona: %w", err)
+	}
+
+	current, err := pm.newPersonalityManager(persona)
+	if err != nil {
+		return err
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.persona = persona
+	pm.current = current
+
+	return nil
+}
+
+// newPersonalityManager creates the zen personality manager of a persona
+func (pm *PersonaManager) newPersonalityManager(persona *Persona) (*personality.PersonalityManager, error) {
+	current, err := personality.NewPersonalityManager(
+		pm.baseOpts,
+		personality.WithPersonality(persona.Personality()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create personality manager: %w", err)
+	}
+	return current, nil
+}
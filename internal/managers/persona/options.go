@@ -0,0 +1,26 @@
+package persona
+
+import (
+	"fmt"
+
+	"github.com/soralabs/zen/options"
+)
+
+// ValidateRequiredFields checks that a valid persona is set
+func (pm *PersonaManager) ValidateRequiredFields() error {
+	if pm.persona == nil {
+		return fmt.Errorf("persona is required")
+	}
+	if err := pm.persona.Validate(); err != nil {
+		return fmt.Errorf("invalid persona: %w", err)
+	}
+	return nil
+}
+
+// WithPersona sets the persona the agent starts with
+func WithPersona(persona *Persona) options.Option[PersonaManager] {
+	return func(pm *PersonaManager) error {
+		pm.persona = persona
+		return nil
+	}
+}
@@ -0,0 +1,166 @@
+package persona
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/soralabs/zen/managers/personality"
+	"gopkg.in/yaml.v3"
+)
+
+// Persona is the personality of an agent as defined in a persona file
+type Persona struct {
+	Name string `json:"name" yaml:"name"`
+	// Version is recorded on every fragment generated with the persona
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description" yaml:"description"`
+
+	Style      []string `json:"style" yaml:"style"`
+	Traits     []string `json:"traits" yaml:"traits"`
+	Background []string `json:"background,omitempty" yaml:"background,omitempty"`
+	Expertise  []string `json:"expertise,omitempty" yaml:"expertise,omitempty"`
+
+	MessageExamples      []MessageExample   `json:"message_examples,omitempty" yaml:"message_examples,omitempty"`
+	ConversationExamples [][]MessageExample `json:"conversation_examples,omitempty" yaml:"conversation_examples,omitempty"`
+}
+
+// MessageExample is a message of an example, User is who sent it
+type MessageExample struct {
+	User    string `json:"user" yaml:"user"`
+	Content string `json:"content" yaml:"content"`
+}
+
+// Load reads and validates a YAML or JSON persona file, the format is picked by the extension.
+// Unknown fields are rejected so typos don't silently drop part of the persona.
+func Load(path string) (*Persona, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persona file: %w", err)
+	}
+
+	var persona Persona
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&persona); err != nil {
+			return nil, fmt.Errorf("failed to parse persona file: %w", err)
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&persona); err != nil {
+			return nil, fmt.Errorf("failed to parse persona file: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("persona file %s must be .yaml, .yml or .json", path)
+	}
+
+	if err := persona.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid persona %s: %w", path, err)
+	}
+
+	return &persona, nil
+}
+
+// Validate checks that the persona has everything the prompts rely on.
+// Every problem is reported, not just the first one.
+func (p *Persona) Validate() error {
+	var errs []error
+	if strings.TrimSpace(p.Name) == "" {
+		errs = append(errs, fmt.Errorf("name is required"))
+	}
+	if strings.TrimSpace(p.Version) == "" {
+		errs = append(errs, fmt.Errorf("version is required"))
+	}
+	if strings.TrimSpace(p.Description) == "" {
+		errs = append(errs, fmt.Errorf("description is required"))
+	}
+	if len(p.Style) == 0 {
+		errs = append(errs, fmt.Errorf("at least one style is required"))
+	}
+	if len(p.Traits) == 0 {
+		errs = append(errs, fmt.Errorf("at least one trait is required"))
+	}
+
+	for _, field := range []struct {
+		name   string
+		values []string
+	}{
+		{"style", p.Style},
+		{"traits", p.Traits},
+		{"background", p.Background},
+		{"expertise", p.Expertise},
+	} {
+		for i, value := range field.values {
+			if strings.TrimSpace(value) == "" {
+				errs = append(errs, fmt.Errorf("%s %d is empty", field.name, i))
+			}
+		}
+	}
+
+	for i, example := range p.MessageExamples {
+		if err := example.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("message example %d: %w", i, err))
+		}
+	}
+	for i, conversation := range p.ConversationExamples {
+		if len(conversation) == 0 {
+			errs = append(errs, fmt.Errorf("conversation example %d has no messages", i))
+		}
+		for j, example := range conversation {
+			if err := example.validate(); err != nil {
+				errs = append(errs, fmt.Errorf("conversation example %d, message %d: %w", i, j, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validate checks that the example says who sent what
+func (e MessageExample) validate() error {
+	if strings.TrimSpace(e.User) == "" {
+		return fmt.Errorf("user is required")
+	}
+	if strings.TrimSpace(e.Content) == "" {
+		return fmt.Errorf("content is required")
+	}
+	return nil
+}
+
+// Personality converts the persona to the zen personality the prompts are built from
+func (p *Persona) Personality() *personality.Personality {
+	conversationExamples := make([][]personality.MessageExample, 0, len(p.ConversationExamples))
+	for _, conversation := range p.ConversationExamples {
+		conversationExamples = append(conversationExamples, messageExamples(conversation))
+	}
+
+	return &personality.Personality{
+		Name:                 p.Name,
+		Description:          p.Description,
+		Style:                p.Style,
+		Traits:               p.Traits,
+		Background:           p.Background,
+		Expertise:            p.Expertise,
+		MessageExamples:      messageExamples(p.MessageExamples),
+		ConversationExamples: conversationExamples,
+	}
+}
+
+// messageExamples converts examples to their zen counterparts
+func messageExamples(examples []MessageExample) []personality.MessageExample {
+	converted := make([]personality.MessageExample, 0, len(examples))
+	for _, example := range examples {
+		converted = append(converted, personality.MessageExample{
+			User:    example.User,
+			Content: example.Content,
+		})
+	}
+	return converted
+}
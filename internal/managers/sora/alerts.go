@@ -0,0 +1,222 @@
+package sora_manager
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/soralabs/hana/internal/marketdata"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/stores"
+)
+
+// Alerts returns the queue of fired market events.
+// Events are dropped while the queue is full and fire again on a later check.
+func (s *SoraManager) Alerts() <-chan MarketEvent {
+	return s.alerts
+}
+
+// alertLoop checks the watched tokens for alerts on each poll interval until stopped
+func (s *SoraManager) alertLoop() {
+	ticker := time.NewTicker(s.alertConfig.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.Ctx.Done():
+			s.Logger.Infof("Market alerts stopped")
+			return
+		case <-s.stopChan:
+			s.Logger.Infof("Market alerts stopped")
+			return
+		case <-ticker.C:
+			s.checkAlerts()
+		}
+	}
+}
+
+// checkAlerts fires the events of every watched token whose role alerts are raised for
+func (s *SoraManager) checkAlerts() {
+	for _, token := range s.watchlist {
+		if !slices.Contains(s.alertConfig.Roles, token.Role) {
+			continue
+		}
+
+		events, err := s.detectEvents(token)
+		if err != nil {
+			s.Logger.Warnf("failed to check alerts for %s: %v", token.Name, err)
+			continue
+		}
+
+		for _, event := range events {
+			if err := s.fireEvent(event); err != nil {
+				s.Logger.Warnf("failed to fire %s alert for %s: %v", event.Type, token.Name, err)
+			}
+		}
+	}
+}
+
+// detectEvents compares the token's current market data to the configured conditions
+// and its recorded snapshots
+func (s *SoraManager) detectEvents(token WatchedToken) ([]MarketEvent, error) {
+	market, err := s.getTokenMarket(token)
+	if err != nil {
+		return nil, err
+	}
+	price := market.PriceUsd
+
+	now := time.Now()
+	newEvent := func(alertType AlertType, key string, description string) MarketEvent {
+		return MarketEvent{
+			Type:        alertType,
+			Token:       token,
+			Key:         fmt.Sprintf("%s_%s_%s", token.Mint, alertType, key),
+			Description: description,
+			Timestamp:   now,
+		}
+	}
+
+	var events []MarketEvent
+
+	// conditions on trading activity are skipped while only prices are available
+	activity := market.Activity
+	if activity != nil {
+		if move := activity.PriceChange.H1; math.Abs(move) >= s.alertConfig.PriceMoveThreshold {
+			direction := "up"
+			if move < 0 {
+				direction = "down"
+			}
+			events = append(events, newEvent(AlertPriceMove, direction,
+				fmt.Sprintf("%s is %s %.2f%% in the last hour, now at %.6g USD.", token.Name, direction, math.Abs(move), price)))
+		}
+
+		if spike, ok := volumeSpike(activity.Volume, s.alertConfig.VolumeSpikeMultiplier); ok {
+			events = append(events, newEvent(AlertVolumeSpike, "",
+				fmt.Sprintf("%s traded %.2f USD in the last hour, %.1fx its average hourly volume over the last 24h.", token.Name, activity.Volume.H1, spike)))
+		}
+	}
+
+	// the remaining conditions need a recorded history to compare against
+	previous, err := s.latestSnapshot(token)
+	if err != nil {
+		return nil, err
+	}
+	if previous == nil {
+		return events, nil
+	}
+
+	if previous.AthUsd > 0 && price > previous.AthUsd {
+		events = append(events, newEvent(AlertNewATH, "",
+			fmt.Sprintf("%s hit a new all-time high of %.6g USD, the previous high was %.6g USD.", token.Name, price, previous.AthUsd)))
+	}
+
+	if activity == nil {
+		return events, nil
+	}
+
+	for _, milestone := range s.alertConfig.MarketCapMilestones {
+		if previous.MarketCap < milestone && activity.MarketCap >= milestone {
+			events = append(events, newEvent(AlertMarketCapMilestone, strconv.FormatFloat(milestone, 'f', 0, 64),
+				fmt.Sprintf("%s crossed a market cap of %.0f USD, now at %.2f USD.", token.Name, milestone, activity.MarketCap)))
+		}
+	}
+
+	since := now.Add(-s.alertConfig.LiquidityLookback)
+	snapshots, err := s.searchSnapshots(token, &since, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) > 0 {
+		// snapshots are newest first
+		before := snapshots[len(snapshots)-1].LiquidityUsd
+		if drop := percentBelow(activity.LiquidityUsd, before); drop >= s.alertConfig.LiquidityDropThreshold {
+			events = append(events, newEvent(AlertLiquidityDrop, "",
+				fmt.Sprintf("%s liquidity dropped %.2f%% from %.2f USD to %.2f USD within %v.", token.Name, drop, before, activity.LiquidityUsd, s.alertConfig.LiquidityLookback)))
+		}
+	}
+
+	return events, nil
+}
+
+// volumeSpike returns how many times the average hourly volume of the last 24h the last hour traded
+func volumeSpike(volume marketdata.Windows, multiplier float64) (float64, bool) {
+	if volume.H24 <= 0 {
+		return 0, false
+	}
+
+	spike := volume.H1 / (volume.H24 / 24)
+	return spike, spike >= multiplier
+}
+
+// fireEvent records the event and queues it, unless the same event fired within the cooldown.
+// The event is only queued once it is recorded, so its cooldown holds even if recording fails.
+func (s *SoraManager) fireEvent(event MarketEvent) error {
+	coolingDown, err := s.isCoolingDown(event)
+	if err != nil {
+		return err
+	}
+	if coolingDown {
+		return nil
+	}
+
+	fragment := &db.Fragment{
+		ID:        id.New(),
+		ActorID:   s.AssistantID,
+		SessionID: marketSessionID,
+		Content:   event.Description,
+		Embedding: pgvector.NewVector(make([]float32, 1536)),
+		Metadata: db.Metadata{
+			"type":       FragmentTypeMarketAlert,
+			"alert_type": event.Type,
+			"alert_key":  event.Key,
+			"mint":       event.Token.Mint,
+		},
+		CreatedAt: event.Timestamp,
+		UpdatedAt: event.Timestamp,
+	}
+	if err := s.Store(fragment); err != nil {
+		return fmt.Errorf("failed to store alert: %w", err)
+	}
+
+	select {
+	case s.alerts <- event:
+	default:
+		// the dropped event starts no cooldown, so it fires again on a later check
+		if err := s.FragmentStore.DeleteByID(fragment.ID); err != nil {
+			return fmt.Errorf("alert queue is full, and failed to delete the stored alert: %w", err)
+		}
+		return fmt.Errorf("alert queue is full")
+	}
+
+	s.Logger.WithFields(map[string]interface{}{
+		"token":       event.Token.Name,
+		"type":        event.Type,
+		"description": event.Description,
+	}).Infof("Market alert fired")
+
+	return nil
+}
+
+// isCoolingDown reports whether the event already fired within the cooldown.
+// Alerts are looked up in storage so cooldowns survive restarts.
+func (s *SoraManager) isCoolingDown(event MarketEvent) (bool, error) {
+	since := event.Timestamp.Add(-s.alertConfig.Cooldown)
+	alerts, err := s.FragmentStore.SearchByFilter(stores.FragmentFilter{
+		SessionID: &marketSessionID,
+		Metadata: []stores.MetadataCondition{
+			{Key: "type", Value: FragmentTypeMarketAlert, Operator: stores.MetadataOpEquals},
+			{Key: "alert_key", Value: event.Key, Operator: stores.MetadataOpEquals},
+		},
+		StartTime: &since,
+		Limit:     1,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to search alerts: %w", err)
+	}
+
+	return len(alerts) > 0, nil
+}
@@ -0,0 +1,68 @@
+package sora_manager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFireEvent(t *testing.T) {
+	token := WatchedToken{Mint: SoraMintAddress, Name: "SORA", Role: TokenRoleOurs}
+
+	// newEvent returns a price move of the token
+	newEvent := func() MarketEvent {
+		return MarketEvent{
+			Type:        AlertPriceMove,
+			Token:       token,
+			Key:         token.Mint + "_price_move_up",
+			Description: "SORA is up 25% in the last hour.",
+			Timestamp:   time.Now(),
+		}
+	}
+
+	t.Run("cooldown", func(t *testing.T) {
+		s := newTestSora(t)
+		if err := s.fireEvent(newEvent()); err != nil {
+			t.Fatalf("fireEvent: %v", err)
+		}
+		<-s.alerts
+
+		if err := s.fireEvent(newEvent()); err != nil {
+			t.Fatalf("fireEvent within the cooldown: %v", err)
+		}
+		if len(s.alerts) != 0 {
+			t.Error("queued an event within its cooldown")
+		}
+	})
+
+	t.Run("store failure", func(t *testing.T) {
+		s := newTestSora(t)
+		if err := s.database.Exec("DROP TABLE sora").Error; err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.fireEvent(newEvent()); err == nil {
+			t.Fatal("got no error for an alert that couldn't be stored")
+		}
+		if len(s.alerts) != 0 {
+			t.Error("queued an event that wasn't stored")
+		}
+	})
+
+	t.Run("full queue", func(t *testing.T) {
+		s := newTestSora(t)
+		s.alerts <- MarketEvent{}
+
+		if err := s.fireEvent(newEvent()); err == nil {
+			t.Fatal("got no error for a full queue")
+		}
+
+		// the dropped event fires again once the queue has room
+		<-s.alerts
+		if err := s.fireEvent(newEvent()); err != nil {
+			t.Fatalf("fireEvent after the queue drained: %v", err)
+		}
+		if len(s.alerts) != 1 {
+			t.Error("the dropped event started a cooldown")
+		}
+	})
+}
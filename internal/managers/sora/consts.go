@@ -1,6 +1,9 @@
 package sora_manager
 
 import (
+	"errors"
+	"time"
+
 	"github.com/soralabs/zen/db"
 	"github.com/soralabs/zen/manager"
 	"github.com/soralabs/zen/state"
@@ -17,8 +20,140 @@ const (
 const (
 	SoraInformation state.StateDataKey = "sora_information"
 	SoraTokenData   state.StateDataKey = "sora_token_data"
+
+	// WatchlistTokenData holds the summaries of every watched token
+	WatchlistTokenData state.StateDataKey = "watchlist_token_data"
+
+	// SoraTokenTrends holds the price history summary of our token
+	SoraTokenTrends state.StateDataKey = "sora_token_trends"
+	// WatchlistTokenTrends holds the price history summaries of every watched token
+	WatchlistTokenTrends state.StateDataKey = "watchlist_token_trends"
+
+	// SoraOnchainData holds the supply, holder and large transfer summary of our token
+	SoraOnchainData state.StateDataKey = "sora_onchain_data"
+	// SoraWhaleActivity holds the recent whale transfers of our token
+	SoraWhaleActivity state.StateDataKey = "sora_whale_activity"
+)
+
+const (
+	FragmentTypeTokenSnapshot string = "token_snapshot"
+	FragmentTypeMarketAlert   string = "market_alert"
+	FragmentTypeWhaleTransfer string = "whale_transfer"
+
+	// DefaultSnapshotInterval is how often watched tokens are snapshotted
+	DefaultSnapshotInterval = 30 * time.Minute
+)
+
+const (
+	// TokenRoleOurs is Sora's own token, its summary is also provided as SoraTokenData
+	TokenRoleOurs      TokenRole = "ours"
+	TokenRoleEcosystem TokenRole = "ecosystem"
 )
 
+const (
+	AlertPriceMove          AlertType = "price_move"
+	AlertLiquidityDrop      AlertType = "liquidity_drop"
+	AlertVolumeSpike        AlertType = "volume_spike"
+	AlertNewATH             AlertType = "new_ath"
+	AlertMarketCapMilestone AlertType = "market_cap_milestone"
+	AlertWhaleTransfer      AlertType = "whale_transfer"
+)
+
+// alertQueueSize is how many fired alerts can wait for a consumer
+const alertQueueSize = 16
+
 const (
 	SoraMintAddress string = "89nnWMkWeF9LSJvAWcN2JFQfeWdDk6diKEckeToEU1hE"
 )
+
+// DefaultWatchlist only tracks the Sora token
+var DefaultWatchlist = []WatchedToken{
+	{Mint: SoraMintAddress, Name: "Sora", Role: TokenRoleOurs},
+}
+
+// DefaultAlertConfig only alerts on our own token
+var DefaultAlertConfig = AlertConfig{
+	PollInterval:           5 * time.Minute,
+	Roles:                  []TokenRole{TokenRoleOurs},
+	PriceMoveThreshold:     20,
+	LiquidityDropThreshold: 25,
+	LiquidityLookback:      time.Hour,
+	VolumeSpikeMultiplier:  3,
+	MarketCapMilestones:    []float64{1_000_000, 5_000_000, 10_000_000, 50_000_000, 100_000_000},
+	Cooldown:               6 * time.Hour,
+}
+
+// DefaultOnchainConfig counts the full supply as circulating
+var DefaultOnchainConfig = OnchainConfig{
+	RefreshInterval:    15 * time.Minute,
+	LargeTransferShare: 0.1,
+	TransferLookback:   50,
+}
+
+const (
+	WalletKindTeam WalletKind = "team"
+	WalletKindLP   WalletKind = "lp"
+	WalletKindCEX  WalletKind = "cex"
+)
+
+const (
+	// WhaleDirectionBuy is a transfer out of a liquidity pool
+	WhaleDirectionBuy WhaleDirection = "buy"
+	// WhaleDirectionSell is a transfer into a liquidity pool
+	WhaleDirectionSell WhaleDirection = "sell"
+	// WhaleDirectionDeposit is a transfer into an exchange wallet
+	WhaleDirectionDeposit WhaleDirection = "deposit"
+	// WhaleDirectionWithdrawal is a transfer out of an exchange wallet
+	WhaleDirectionWithdrawal WhaleDirection = "withdrawal"
+	WhaleDirectionTransfer   WhaleDirection = "transfer"
+)
+
+// DefaultWhaleConfig has no known wallets, so only buys and sells through labelled pools are told apart
+var DefaultWhaleConfig = WhaleConfig{
+	PollInterval:  2 * time.Minute,
+	ThresholdUsd:  10_000,
+	BatchSize:     25,
+	ContextWindow: 24 * time.Hour,
+}
+
+// whaleEventMaxAge is how old a whale transfer can be to still be tweeted about,
+// older ones found on the first scan are only recorded
+const whaleEventMaxAge = time.Hour
+
+const (
+	HolderTierNone  HolderTier = "none"
+	HolderTierSmall HolderTier = "small"
+	HolderTierLarge HolderTier = "large"
+	HolderTierWhale HolderTier = "whale"
+)
+
+const (
+	// WalletLinkSignature links are proven by signing the link challenge with the wallet
+	WalletLinkSignature WalletLinkMethod = "signature"
+	// WalletLinkBio links are declared in the actor's profile bio
+	WalletLinkBio WalletLinkMethod = "bio"
+)
+
+var (
+	// ErrWalletLinked is returned when a wallet declared in a bio is already linked to another actor
+	ErrWalletLinked = errors.New("wallet is linked to another actor")
+	// ErrNonceUsed is returned for a signed wallet link whose nonce linked a wallet before
+	ErrNonceUsed = errors.New("wallet link nonce was already used")
+)
+
+const (
+	// walletLinkChallengeMaxAge is how long after it was issued a signed link challenge can be posted
+	walletLinkChallengeMaxAge = 15 * time.Minute
+	// walletLinkClockSkew is how far in the future a link challenge can be issued, for clocks running ahead
+	walletLinkClockSkew = time.Minute
+)
+
+// DefaultHolderTiers rank any balance as small, balances below every threshold are HolderTierNone
+var DefaultHolderTiers = []HolderTierThreshold{
+	{Tier: HolderTierWhale, MinBalance: 10_000_000},
+	{Tier: HolderTierLarge, MinBalance: 1_000_000},
+	{Tier: HolderTierSmall, MinBalance: 1},
+}
+
+// topHolderAccounts is how many of the largest token accounts the concentration is computed over
+const topHolderAccounts = 10
@@ -2,46 +2,83 @@ package sora_manager
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
-	"github.com/soralabs/hana/internal/dexscreener"
-	"github.com/soralabs/zen/cache"
+	"github.com/soralabs/hana/internal/marketdata"
+	"github.com/soralabs/zen/state"
 )
 
-func (s *SoraManager) getSoraTokenData() (string, error) {
-	cacheKey := cache.CacheKey("sora_token_data")
-	cacheValue, exists := s.cache.Get(cacheKey)
-	if exists {
-		return cacheValue.(string), nil
+var tokenKeyRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// TokenDataKey returns the state data key holding a watched token's summary
+func TokenDataKey(token WatchedToken) state.StateDataKey {
+	return state.StateDataKey("token_data_" + tokenKeySuffix(token))
+}
+
+// TokenTrendsKey returns the state key of a watched token's price history summary
+func TokenTrendsKey(token WatchedToken) state.StateDataKey {
+	return state.StateDataKey("token_trends_" + tokenKeySuffix(token))
+}
+
+func tokenKeySuffix(token WatchedToken) string {
+	return strings.Trim(tokenKeyRegex.ReplaceAllString(strings.ToLower(token.Name), "_"), "_")
+}
+
+// getTokenMarket returns the token's market data from the market data provider
+func (s *SoraManager) getTokenMarket(token WatchedToken) (*marketdata.TokenMarket, error) {
+	market, err := s.marketData.TokenMarket(s.Ctx, token.Mint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get market data: %w", err)
 	}
+	return market, nil
+}
 
-	data, err := dexscreener.GetPairInformation(s.Ctx, SoraMintAddress)
+func (s *SoraManager) getTokenData(token WatchedToken) (string, error) {
+	market, err := s.getTokenMarket(token)
 	if err != nil {
-		return "", fmt.Errorf("failed to get pair information: %w", err)
+		return "", err
+	}
+
+	role := "an ecosystem token"
+	if token.Role == TokenRoleOurs {
+		role = "our token"
+	}
+
+	symbol := market.Symbol
+	if symbol == "" {
+		symbol = strings.ToUpper(token.Name)
 	}
 
-	if len(data) == 0 {
-		return "", fmt.Errorf("no data found")
+	// price only providers don't report trading activity
+	activity := market.Activity
+	if activity == nil {
+		return fmt.Sprintf("%s ($%s, %s) trading summary: Price: %.6g USD. No trading activity available right now.",
+			token.Name,
+			symbol,
+			role,
+			market.PriceUsd), nil
 	}
 
-	tokenInfo := data[0]
-	summary := fmt.Sprintf("Sora trading summary: Price: %s USD (%s native). Volumes: 24h: %.2f USD, 1h: %.2f USD. Market metrics: Cap: %.2f USD, FDV: %.2f USD, Liquidity: %.2f USD. Transactions: 24h - %d buys, %d sells; 1h - %d buys, %d sells. Price changes: 5m: %.2f%%, 1h: %.2f%%, 6h: %.2f%%, 24h: %.2f%%.",
-		tokenInfo.PriceUsd,
-		tokenInfo.PriceNative,
-		tokenInfo.Volume.H24,
-		tokenInfo.Volume.H1,
-		tokenInfo.MarketCap,
-		tokenInfo.Fdv,
-		tokenInfo.Liquidity.Usd,
-		tokenInfo.Txns.H24.Buys,
-		tokenInfo.Txns.H24.Sells,
-		tokenInfo.Txns.H1.Buys,
-		tokenInfo.Txns.H1.Sells,
-		tokenInfo.PriceChange.M5,
-		tokenInfo.PriceChange.H1,
-		tokenInfo.PriceChange.H6,
-		tokenInfo.PriceChange.H24)
-
-	s.cache.Set(cacheKey, summary)
+	summary := fmt.Sprintf("%s ($%s, %s) trading summary: Price: %.6g USD (%s native). Volumes: 24h: %.2f USD, 1h: %.2f USD. Market metrics: Cap: %.2f USD, FDV: %.2f USD, Liquidity: %.2f USD. Transactions: 24h - %d buys, %d sells; 1h - %d buys, %d sells. Price changes: 5m: %.2f%%, 1h: %.2f%%, 6h: %.2f%%, 24h: %.2f%%.",
+		token.Name,
+		symbol,
+		role,
+		market.PriceUsd,
+		market.PriceNative,
+		activity.Volume.H24,
+		activity.Volume.H1,
+		activity.MarketCap,
+		activity.Fdv,
+		activity.LiquidityUsd,
+		activity.Txns.H24.Buys,
+		activity.Txns.H24.Sells,
+		activity.Txns.H1.Buys,
+		activity.Txns.H1.Sells,
+		activity.PriceChange.M5,
+		activity.PriceChange.H1,
+		activity.PriceChange.H6,
+		activity.PriceChange.H24)
 
 	return summary, nil
 }
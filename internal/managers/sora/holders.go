@@ -0,0 +1,231 @@
+package sora_manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/soralabs/zen/cache"
+	"github.com/soralabs/zen/id"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VerifyWalletSignature checks that the base58 encoded signature of the message was made by the wallet
+func VerifyWalletSignature(wallet, message, signature string) error {
+	publicKey, err := solana.PublicKeyFromBase58(wallet)
+	if err != nil {
+		return fmt.Errorf("invalid wallet %s: %w", wallet, err)
+	}
+
+	decoded, err := solana.SignatureFromBase58(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	if !decoded.Verify(publicKey, []byte(message)) {
+		return fmt.Errorf("signature was not made by %s", wallet)
+	}
+
+	return nil
+}
+
+// WalletLinkChallenge returns the message a user signs with their wallet to link it to their account.
+// The nonce is chosen for each link and the time it was issued bounds how long the signature can be used,
+// so a signature can neither be replayed nor used by whoever takes the handle over later.
+func WalletLinkChallenge(username, nonce string, issuedAt time.Time) string {
+	return fmt.Sprintf("I am @%s and this is my wallet\nNonce: %s\nIssued at: %d", strings.ToLower(username), nonce, issuedAt.Unix())
+}
+
+// LinkSignedWallet links the wallet of a signed link challenge posted at postedAt to the actor.
+// The challenge must have been issued at most walletLinkChallengeMaxAge before it was posted,
+// and its nonce is used up by the link, a second link with it returns ErrNonceUsed.
+func (s *SoraManager) LinkSignedWallet(actorID id.ID, proof WalletLinkProof, postedAt time.Time) error {
+	if postedAt.Before(proof.IssuedAt.Add(-walletLinkClockSkew)) || postedAt.Sub(proof.IssuedAt) > walletLinkChallengeMaxAge {
+		return fmt.Errorf("link challenge issued at %s wasn't posted within %s", proof.IssuedAt.UTC().Format(time.RFC3339), walletLinkChallengeMaxAge)
+	}
+
+	challenge := WalletLinkChallenge(proof.Username, proof.Nonce, proof.IssuedAt)
+	if err := VerifyWalletSignature(proof.Wallet, challenge, proof.Signature); err != nil {
+		return err
+	}
+
+	return s.linkWallet(actorID, proof.Wallet, WalletLinkSignature, func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&WalletLinkNonce{
+			Nonce:   proof.Nonce,
+			ActorID: actorID,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to store wallet link nonce: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNonceUsed
+		}
+		return nil
+	})
+}
+
+// LinkWallet links a wallet to the actor, replacing the wallet the actor linked before.
+// A wallet declared in a bio doesn't replace one verified by signature, and can't claim a wallet
+// another actor linked, which returns ErrWalletLinked. A signature proves the wallet is the actor's,
+// so it takes the wallet over from any other actor.
+// The caller has checked the proof of the method, signed links are made with LinkSignedWallet.
+func (s *SoraManager) LinkWallet(actorID id.ID, wallet string, method WalletLinkMethod) error {
+	return s.linkWallet(actorID, wallet, method, nil)
+}
+
+// linkWallet links a wallet to the actor, before runs in the transaction that stores the link
+func (s *SoraManager) linkWallet(actorID id.ID, wallet string, method WalletLinkMethod, before func(tx *gorm.DB) error) error {
+	if _, err := solana.PublicKeyFromBase58(wallet); err != nil {
+		return fmt.Errorf("invalid wallet %s: %w", wallet, err)
+	}
+
+	existing, err := s.GetWalletLink(actorID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && (existing.Wallet == wallet && existing.Method == method || existing.Verified && method != WalletLinkSignature) {
+		return nil
+	}
+
+	var previousOwners []ActorWallet
+	if err := s.database.WithContext(s.Ctx).Transaction(func(tx *gorm.DB) error {
+		if before != nil {
+			if err := before(tx); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("wallet = ? AND actor_id <> ?", wallet, actorID).Find(&previousOwners).Error; err != nil {
+			return fmt.Errorf("failed to get wallet owner: %w", err)
+		}
+		if len(previousOwners) > 0 {
+			if method != WalletLinkSignature {
+				return ErrWalletLinked
+			}
+			if err := tx.Where("wallet = ? AND actor_id <> ?", wallet, actorID).Delete(&ActorWallet{}).Error; err != nil {
+				return fmt.Errorf("failed to unlink wallet from its previous owner: %w", err)
+			}
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "actor_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"wallet", "method", "verified", "updated_at"}),
+		}).Create(&ActorWallet{
+			ActorID:  actorID,
+			Wallet:   wallet,
+			Method:   method,
+			Verified: method == WalletLinkSignature,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to store wallet link: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	s.Cache.Delete(holderStatusCacheKey(actorID))
+	for _, owner := range previousOwners {
+		s.Cache.Delete(holderStatusCacheKey(owner.ActorID))
+		s.Logger.WithFields(map[string]interface{}{
+			"actor_id": owner.ActorID,
+			"wallet":   wallet,
+		}).Infof("Unlinked wallet claimed by signature")
+	}
+
+	s.Logger.WithFields(map[string]interface{}{
+		"actor_id": actorID,
+		"wallet":   wallet,
+		"method":   method,
+	}).Infof("Linked wallet")
+
+	return nil
+}
+
+// GetWalletLink returns the wallet linked to the actor, nil for actors without one
+func (s *SoraManager) GetWalletLink(actorID id.ID) (*WalletLink, error) {
+	var wallets []ActorWallet
+	if err := s.database.WithContext(s.Ctx).Where("actor_id = ?", actorID).Limit(1).Find(&wallets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get wallet link: %w", err)
+	}
+	if len(wallets) == 0 {
+		return nil, nil
+	}
+
+	return &WalletLink{
+		Wallet:   wallets[0].Wallet,
+		Method:   wallets[0].Method,
+		Verified: wallets[0].Verified,
+	}, nil
+}
+
+// HolderStatus returns the holder tier of the actor's linked wallet.
+// Returns nil for actors without a link and when no RPC client is set.
+func (s *SoraManager) HolderStatus(actorID id.ID) (*HolderStatus, error) {
+	if s.solanaRPC == nil {
+		return nil, nil
+	}
+
+	cacheKey := holderStatusCacheKey(actorID)
+	if cached, exists := s.Cache.Get(cacheKey); exists {
+		return cached.(*HolderStatus), nil
+	}
+
+	link, err := s.GetWalletLink(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var status *HolderStatus
+	if link != nil {
+		balance, err := s.walletTokenBalance(s.Ctx, solana.MustPublicKeyFromBase58(link.Wallet), solana.MustPublicKeyFromBase58(s.soraToken().Mint))
+		if err != nil {
+			return nil, err
+		}
+		status = &HolderStatus{
+			WalletLink: *link,
+			Balance:    balance,
+			Tier:       s.holderTier(balance),
+		}
+	}
+
+	s.Cache.Set(cacheKey, status)
+
+	return status, nil
+}
+
+// holderTier returns the highest tier whose threshold the balance reaches
+func (s *SoraManager) holderTier(balance float64) HolderTier {
+	tiers := append([]HolderTierThreshold(nil), s.holderTiers...)
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MinBalance > tiers[j].MinBalance
+	})
+
+	for _, threshold := range tiers {
+		if balance >= threshold.MinBalance {
+			return threshold.Tier
+		}
+	}
+	return HolderTierNone
+}
+
+// DescribeHolderStatus formats a holder status for prompts, the wallet is shortened
+func (s *SoraManager) DescribeHolderStatus(status *HolderStatus) string {
+	proof := "declared in their bio"
+	if status.Verified {
+		proof = "verified by signature"
+	}
+
+	if status.Tier == HolderTierNone {
+		return fmt.Sprintf("They linked wallet %s (%s) but hold no %s.", shortAddress(status.Wallet), proof, s.soraToken().Name)
+	}
+
+	return fmt.Sprintf("They are a %s tier %s holder with %s %s in wallet %s (%s).",
+		status.Tier, s.soraToken().Name, formatAmount(status.Balance), s.soraToken().Name, shortAddress(status.Wallet), proof)
+}
+
+func holderStatusCacheKey(actorID id.ID) cache.CacheKey {
+	return cache.CacheKey(fmt.Sprintf("holder_status_%s", actorID))
+}
@@ -0,0 +1,296 @@
+package sora_manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/soralabs/hana/internal/testutil"
+	"github.com/soralabs/zen/cache"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/manager"
+	"github.com/soralabs/zen/stores"
+)
+
+// newTestSora creates a sora manager that links wallets and fires alerts, on a temporary sqlite database.
+// It has no market data or RPC client.
+func newTestSora(t *testing.T) *SoraManager {
+	t.Helper()
+
+	database := testutil.NewDatabase(t)
+	testutil.CreateFragmentTables(t, database, FragmentTableSora)
+	// wallet links reference the actors table, so they are migrated after it exists
+	if err := database.AutoMigrate(&ActorWallet{}, &WalletLinkNonce{}); err != nil {
+		t.Fatalf("failed to migrate actor wallets: %v", err)
+	}
+	// fragments are only found with their actor
+	if err := database.Create(&db.Actor{ID: id.FromString("hana"), Name: "hana", Assistant: true}).Error; err != nil {
+		t.Fatalf("failed to create the assistant: %v", err)
+	}
+
+	return &SoraManager{
+		BaseManager: &manager.BaseManager{
+			Ctx:           context.Background(),
+			AssistantID:   id.FromString("hana"),
+			FragmentStore: stores.NewFragmentStore(context.Background(), database, FragmentTableSora),
+			Cache: cache.New(cache.Config{
+				MaxSize:       100,
+				TTL:           15 * time.Minute,
+				CleanupPeriod: time.Minute,
+			}),
+			Logger: testutil.NewLogger(t),
+		},
+		database:    database,
+		alertConfig: DefaultAlertConfig,
+		alerts:      make(chan MarketEvent, 1),
+	}
+}
+
+func TestLinkWallet(t *testing.T) {
+	bioWallet := solana.NewWallet().PublicKey().String()
+	signedWallet := solana.NewWallet().PublicKey().String()
+	otherWallet := solana.NewWallet().PublicKey().String()
+
+	type link struct {
+		wallet string
+		method WalletLinkMethod
+	}
+	tests := []struct {
+		name  string
+		links []link
+		want  *WalletLink
+	}{
+		{
+			name: "no link",
+		},
+		{
+			name:  "bio link",
+			links: []link{{bioWallet, WalletLinkBio}},
+			want:  &WalletLink{Wallet: bioWallet, Method: WalletLinkBio},
+		},
+		{
+			name:  "new bio replaces the old bio",
+			links: []link{{bioWallet, WalletLinkBio}, {otherWallet, WalletLinkBio}},
+			want:  &WalletLink{Wallet: otherWallet, Method: WalletLinkBio},
+		},
+		{
+			name:  "signature replaces a bio",
+			links: []link{{bioWallet, WalletLinkBio}, {signedWallet, WalletLinkSignature}},
+			want:  &WalletLink{Wallet: signedWallet, Method: WalletLinkSignature, Verified: true},
+		},
+		{
+			name:  "bio doesn't replace a signature",
+			links: []link{{signedWallet, WalletLinkSignature}, {bioWallet, WalletLinkBio}},
+			want:  &WalletLink{Wallet: signedWallet, Method: WalletLinkSignature, Verified: true},
+		},
+		{
+			name:  "new signature replaces the old signature",
+			links: []link{{signedWallet, WalletLinkSignature}, {otherWallet, WalletLinkSignature}},
+			want:  &WalletLink{Wallet: otherWallet, Method: WalletLinkSignature, Verified: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSora(t)
+			actorID := id.New()
+
+			for _, link := range tt.links {
+				if err := s.LinkWallet(actorID, link.wallet, link.method); err != nil {
+					t.Fatalf("LinkWallet(%s, %s): %v", link.wallet, link.method, err)
+				}
+			}
+
+			got, err := s.GetWalletLink(actorID)
+			if err != nil {
+				t.Fatalf("GetWalletLink: %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("got link %+v, want %+v", got, tt.want)
+			}
+
+			var rows int64
+			if err := s.database.Model(&ActorWallet{}).Where("actor_id = ?", actorID).Count(&rows).Error; err != nil {
+				t.Fatalf("failed to count wallet links: %v", err)
+			}
+			if rows > 1 {
+				t.Errorf("got %d wallet links, an actor has at most one", rows)
+			}
+		})
+	}
+}
+
+func TestLinkWalletRejectsInvalidWallet(t *testing.T) {
+	s := newTestSora(t)
+	if err := s.LinkWallet(id.New(), "not-a-wallet", WalletLinkBio); err == nil {
+		t.Error("linked an invalid wallet")
+	}
+}
+
+func TestLinkWalletOfAnotherActor(t *testing.T) {
+	tests := []struct {
+		name   string
+		method WalletLinkMethod
+		// wantErr is the error of the second actor's link, nil when they take the wallet over
+		wantErr error
+	}{
+		{
+			name:    "bio claim is refused",
+			method:  WalletLinkBio,
+			wantErr: ErrWalletLinked,
+		},
+		{
+			name:   "signature takes the wallet over",
+			method: WalletLinkSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSora(t)
+			wallet := solana.NewWallet().PublicKey().String()
+			owner, claimer := id.New(), id.New()
+
+			if err := s.LinkWallet(owner, wallet, WalletLinkBio); err != nil {
+				t.Fatalf("LinkWallet of the owner: %v", err)
+			}
+			if err := s.LinkWallet(claimer, wallet, tt.method); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			ownerLink, err := s.GetWalletLink(owner)
+			if err != nil {
+				t.Fatalf("GetWalletLink of the owner: %v", err)
+			}
+			claimerLink, err := s.GetWalletLink(claimer)
+			if err != nil {
+				t.Fatalf("GetWalletLink of the claimer: %v", err)
+			}
+
+			taken := tt.wantErr == nil
+			if (ownerLink == nil) != taken || (claimerLink != nil) != taken {
+				t.Errorf("got owner link %+v and claimer link %+v, want the wallet taken over %v", ownerLink, claimerLink, taken)
+			}
+		})
+	}
+}
+
+func TestLinkSignedWallet(t *testing.T) {
+	signer := solana.NewWallet().PrivateKey
+	issuedAt := time.Now().Truncate(time.Second)
+
+	// sign returns alice's proof of the signer's wallet, its challenge signed with the key
+	sign := func(key solana.PrivateKey, username, nonce string, issuedAt time.Time) WalletLinkProof {
+		signature, err := key.Sign([]byte(WalletLinkChallenge(username, nonce, issuedAt)))
+		if err != nil {
+			t.Fatalf("failed to sign the challenge: %v", err)
+		}
+		return WalletLinkProof{
+			Username:  "alice",
+			Wallet:    signer.PublicKey().String(),
+			Nonce:     nonce,
+			IssuedAt:  issuedAt,
+			Signature: signature.String(),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		proof    WalletLinkProof
+		postedAt time.Time
+		// replayed links the proof, then another wallet, before the checked link of the proof
+		replayed bool
+		wantErr  bool
+	}{
+		{
+			name:     "signed challenge",
+			proof:    sign(signer, "alice", "n0nce001", issuedAt),
+			postedAt: issuedAt.Add(time.Minute),
+		},
+		{
+			name:     "handle in another case",
+			proof:    sign(signer, "ALICE", "n0nce001", issuedAt),
+			postedAt: issuedAt.Add(time.Minute),
+		},
+		{
+			name:     "challenge of another handle",
+			proof:    sign(signer, "bob", "n0nce001", issuedAt),
+			postedAt: issuedAt.Add(time.Minute),
+			wantErr:  true,
+		},
+		{
+			name: "another nonce than signed",
+			proof: func() WalletLinkProof {
+				proof := sign(signer, "alice", "n0nce001", issuedAt)
+				proof.Nonce = "n0nce002"
+				return proof
+			}(),
+			postedAt: issuedAt.Add(time.Minute),
+			wantErr:  true,
+		},
+		{
+			name:     "signed by another wallet",
+			proof:    sign(solana.NewWallet().PrivateKey, "alice", "n0nce001", issuedAt),
+			postedAt: issuedAt.Add(time.Minute),
+			wantErr:  true,
+		},
+		{
+			name:     "expired challenge",
+			proof:    sign(signer, "alice", "n0nce001", issuedAt),
+			postedAt: issuedAt.Add(walletLinkChallengeMaxAge + time.Second),
+			wantErr:  true,
+		},
+		{
+			name:     "challenge issued in the future",
+			proof:    sign(signer, "alice", "n0nce001", issuedAt),
+			postedAt: issuedAt.Add(-2 * walletLinkClockSkew),
+			wantErr:  true,
+		},
+		{
+			name:     "replayed nonce",
+			proof:    sign(signer, "alice", "n0nce001", issuedAt),
+			postedAt: issuedAt.Add(time.Minute),
+			replayed: true,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSora(t)
+			actorID := id.New()
+
+			if tt.replayed {
+				if err := s.LinkSignedWallet(actorID, tt.proof, tt.postedAt); err != nil {
+					t.Fatalf("LinkSignedWallet: %v", err)
+				}
+				// another wallet is linked in between, the replay would switch back to the first
+				other := solana.NewWallet().PrivateKey
+				proof := sign(other, "alice", "n0nce002", issuedAt)
+				proof.Wallet = other.PublicKey().String()
+				if err := s.LinkSignedWallet(actorID, proof, tt.postedAt); err != nil {
+					t.Fatalf("LinkSignedWallet of another wallet: %v", err)
+				}
+			}
+
+			err := s.LinkSignedWallet(actorID, tt.proof, tt.postedAt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.replayed && !errors.Is(err, ErrNonceUsed) {
+				t.Errorf("got error %v, want %v", err, ErrNonceUsed)
+			}
+
+			link, err := s.GetWalletLink(actorID)
+			if err != nil {
+				t.Fatalf("GetWalletLink: %v", err)
+			}
+			if linked := link != nil && link.Wallet == tt.proof.Wallet && link.Verified; linked == tt.wantErr {
+				t.Errorf("got link %+v, want a verified link of the signed wallet %v", link, !tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package sora_manager
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// tokenAccountSize is the size of an SPL token account, used to count holders
+const tokenAccountSize = 165
+
+// onchainLoop refreshes the on-chain metrics of the Sora token on each interval until stopped
+func (s *SoraManager) onchainLoop() {
+	ticker := time.NewTicker(s.onchainConfig.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		s.refreshOnchainMetrics()
+
+		select {
+		case <-s.Ctx.Done():
+			s.Logger.Infof("On-chain metrics stopped")
+			return
+		case <-s.stopChan:
+			s.Logger.Infof("On-chain metrics stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshOnchainMetrics recomputes the metrics, the previous ones are kept when it fails
+func (s *SoraManager) refreshOnchainMetrics() {
+	metrics, err := s.computeOnchainMetrics(s.Ctx, solana.MustPublicKeyFromBase58(SoraMintAddress))
+	if err != nil {
+		s.Logger.Warnf("failed to refresh on-chain metrics: %v", err)
+		return
+	}
+
+	s.onchainMetricsMu.Lock()
+	s.onchainMetrics = metrics
+	s.onchainMetricsMu.Unlock()
+
+	s.Logger.WithFields(map[string]interface{}{
+		"holders":          metrics.HolderCount,
+		"top_holder_share": metrics.TopHolderShare,
+		"large_transfers":  len(metrics.LargeTransfers),
+	}).Infof("Refreshed on-chain metrics")
+}
+
+// getOnchainMetrics returns the last refreshed metrics, nil before the first refresh
+func (s *SoraManager) getOnchainMetrics() *OnchainMetrics {
+	s.onchainMetricsMu.RLock()
+	defer s.onchainMetricsMu.RUnlock()
+	return s.onchainMetrics
+}
+
+// computeOnchainMetrics reads the supply, holders and large transfers of a mint.
+// Only the supply is required, the other metrics are left empty when the RPC node can't serve them.
+func (s *SoraManager) computeOnchainMetrics(ctx context.Context, mint solana.PublicKey) (*OnchainMetrics, error) {
+	supply, err := s.solanaRPC.GetTokenSupply(ctx, mint, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token supply: %w", err)
+	}
+	totalSupply, err := uiAmount(supply.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &OnchainMetrics{
+		Mint:              mint.String(),
+		TotalSupply:       totalSupply,
+		CirculatingSupply: totalSupply,
+		UpdatedAt:         time.Now(),
+	}
+	for _, token := range s.watchlist {
+		if token.Mint == metrics.Mint {
+			metrics.Symbol = token.Name
+		}
+	}
+
+	for _, wallet := range s.onchainConfig.NonCirculatingWallets {
+		balance, err := s.walletTokenBalance(ctx, solana.MustPublicKeyFromBase58(wallet), mint)
+		if err != nil {
+			return nil, err
+		}
+		metrics.CirculatingSupply -= balance
+	}
+
+	if metrics.HolderCount, err = s.countHolders(ctx, mint); err != nil {
+		s.Logger.Warnf("failed to count holders: %v", err)
+	}
+
+	if metrics.TopHolderShare, err = s.topHolderShare(ctx, mint, totalSupply); err != nil {
+		s.Logger.Warnf("failed to get top holder share: %v", err)
+	}
+
+	transfers, err := s.tokenTransfers(ctx, mint, s.onchainConfig.TransferLookback)
+	if err != nil {
+		s.Logger.Warnf("failed to get recent transfers: %v", err)
+	}
+	threshold := totalSupply * s.onchainConfig.LargeTransferShare / 100
+	for _, transfer := range transfers {
+		if transfer.Amount >= threshold {
+			metrics.LargeTransfers = append(metrics.LargeTransfers, transfer)
+		}
+	}
+
+	return metrics, nil
+}
+
+// walletTokenBalance returns the balance of the wallet's associated token account, zero if it has none
+func (s *SoraManager) walletTokenBalance(ctx context.Context, wallet, mint solana.PublicKey) (float64, error) {
+	account, _, err := solana.FindAssociatedTokenAddress(wallet, mint)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find token account of %s: %w", wallet, err)
+	}
+
+	balance, err := s.solanaRPC.GetTokenAccountBalance(ctx, account, rpc.CommitmentFinalized)
+	if err != nil {
+		if strings.Contains(err.Error(), "could not find account") {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get token balance of %s: %w", wallet, err)
+	}
+
+	return uiAmount(balance.Value)
+}
+
+// countHolders counts the mint's token accounts with a non zero balance
+func (s *SoraManager) countHolders(ctx context.Context, mint solana.PublicKey) (int, error) {
+	// only the 8 byte amount is needed to tell empty accounts apart
+	offset, length := uint64(64), uint64(8)
+	accounts, err := s.solanaRPC.GetProgramAccountsWithOpts(ctx, solana.TokenProgramID, &rpc.GetProgramAccountsOpts{
+		Encoding: solana.EncodingBase64,
+		Filters: []rpc.RPCFilter{
+			{DataSize: tokenAccountSize},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: mint.Bytes()}},
+		},
+		DataSlice: &rpc.DataSlice{Offset: &offset, Length: &length},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get token accounts: %w", err)
+	}
+
+	holders := 0
+	for _, account := range accounts {
+		for _, b := range account.Account.Data.GetBinary() {
+			if b != 0 {
+				holders++
+				break
+			}
+		}
+	}
+
+	return holders, nil
+}
+
+// topHolderShare returns the share of the total supply held by the largest token accounts in percent
+func (s *SoraManager) topHolderShare(ctx context.Context, mint solana.PublicKey, totalSupply float64) (float64, error) {
+	if totalSupply <= 0 {
+		return 0, nil
+	}
+
+	largest, err := s.solanaRPC.GetTokenLargestAccounts(ctx, mint, rpc.CommitmentFinalized)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get largest accounts: %w", err)
+	}
+
+	held := 0.0
+	for i, account := range largest.Value {
+		if i >= topHolderAccounts {
+			break
+		}
+		amount, err := uiAmount(&account.UiTokenAmount)
+		if err != nil {
+			return 0, err
+		}
+		held += amount
+	}
+
+	return held / totalSupply * 100, nil
+}
+
+// signaturePageSize is the most signatures the RPC returns per request
+const signaturePageSize = 1000
+
+// tokenTransfers returns the largest movement of the mint in each of its latest successful transactions, newest first
+func (s *SoraManager) tokenTransfers(ctx context.Context, mint solana.PublicKey, limit int) ([]TokenTransfer, error) {
+	signatures, err := s.solanaRPC.GetSignaturesForAddressWithOpts(ctx, mint, &rpc.GetSignaturesForAddressOpts{
+		Limit:      &limit,
+		Commitment: rpc.CommitmentFinalized,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signatures: %w", err)
+	}
+
+	transfers, _, err := s.signatureTransfers(ctx, mint, signatures)
+	return transfers, err
+}
+
+// signaturesSince returns the signatures of the mint's transactions after the until signature, newest first.
+// It pages back until it reaches the until signature, so none are skipped however many arrived.
+func (s *SoraManager) signaturesSince(ctx context.Context, mint solana.PublicKey, until solana.Signature) ([]*rpc.TransactionSignature, error) {
+	limit := signaturePageSize
+	var (
+		signatures []*rpc.TransactionSignature
+		before     solana.Signature
+	)
+	for {
+		page, err := s.solanaRPC.GetSignaturesForAddressWithOpts(ctx, mint, &rpc.GetSignaturesForAddressOpts{
+			Limit:      &limit,
+			Before:     before,
+			Until:      until,
+			Commitment: rpc.CommitmentFinalized,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get signatures: %w", err)
+		}
+
+		signatures = append(signatures, page...)
+		if len(page) < limit {
+			return signatures, nil
+		}
+		before = page[len(page)-1].Signature
+	}
+}
+
+// signatureTransfers reads the transactions of the signatures in order and returns the largest movement
+// of the mint in each successful one. read is how many signatures were read before an error,
+// failed transactions and ones that don't move the mint included.
+func (s *SoraManager) signatureTransfers(ctx context.Context, mint solana.PublicKey, signatures []*rpc.TransactionSignature) (transfers []TokenTransfer, read int, err error) {
+	maxVersion := uint64(0)
+	for i, signature := range signatures {
+		if signature.Err != nil {
+			continue
+		}
+
+		tx, err := s.solanaRPC.GetTransaction(ctx, signature.Signature, &rpc.GetTransactionOpts{
+			Encoding:                       solana.EncodingBase64,
+			Commitment:                     rpc.CommitmentFinalized,
+			MaxSupportedTransactionVersion: &maxVersion,
+		})
+		if err != nil {
+			return transfers, i, fmt.Errorf("failed to get transaction %s: %w", signature.Signature, err)
+		}
+		if tx.Meta == nil {
+			continue
+		}
+
+		transfer, ok := largestTransfer(tx.Meta, mint)
+		if !ok {
+			continue
+		}
+		transfer.Signature = signature.Signature.String()
+		if tx.BlockTime != nil {
+			transfer.Timestamp = tx.BlockTime.Time()
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, len(signatures), nil
+}
+
+// largestTransfer finds the owners that lost and gained the most of the mint in a transaction
+func largestTransfer(meta *rpc.TransactionMeta, mint solana.PublicKey) (TokenTransfer, bool) {
+	deltas := make(map[string]float64)
+	apply := func(balances []rpc.TokenBalance, sign float64) {
+		for _, balance := range balances {
+			if !balance.Mint.Equals(mint) || balance.Owner == nil {
+				continue
+			}
+			amount, err := uiAmount(balance.UiTokenAmount)
+			if err != nil {
+				continue
+			}
+			deltas[balance.Owner.String()] += sign * amount
+		}
+	}
+	apply(meta.PreTokenBalances, -1)
+	apply(meta.PostTokenBalances, 1)
+
+	var transfer TokenTransfer
+	lost := 0.0
+	for owner, delta := range deltas {
+		if delta > transfer.Amount {
+			transfer.To = owner
+			transfer.Amount = delta
+		}
+		if delta < lost {
+			transfer.From = owner
+			lost = delta
+		}
+	}
+
+	return transfer, transfer.Amount > 0
+}
+
+// uiAmount parses a token amount accounting for decimals
+func uiAmount(amount *rpc.UiTokenAmount) (float64, error) {
+	if amount == nil {
+		return 0, fmt.Errorf("missing token amount")
+	}
+	if amount.UiAmountString != "" {
+		return strconv.ParseFloat(amount.UiAmountString, 64)
+	}
+
+	raw, err := strconv.ParseFloat(amount.Amount, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid token amount %q: %w", amount.Amount, err)
+	}
+	return raw / math.Pow10(int(amount.Decimals)), nil
+}
+
+// summarizeOnchainMetrics formats the metrics for prompts.
+// Addresses are shortened so they never show up as contract addresses in a reply.
+func summarizeOnchainMetrics(metrics *OnchainMetrics, now time.Time) string {
+	name := metrics.Symbol
+	if name == "" {
+		name = "Token"
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s on-chain data: total supply %s, circulating supply %s",
+		name, formatAmount(metrics.TotalSupply), formatAmount(metrics.CirculatingSupply)))
+	if metrics.TotalSupply > 0 && metrics.CirculatingSupply < metrics.TotalSupply {
+		builder.WriteString(fmt.Sprintf(" (%.2f%% of total)", metrics.CirculatingSupply/metrics.TotalSupply*100))
+	}
+	if metrics.HolderCount > 0 {
+		builder.WriteString(fmt.Sprintf(", %d holders", metrics.HolderCount))
+	}
+	if metrics.TopHolderShare > 0 {
+		builder.WriteString(fmt.Sprintf(", the %d largest accounts hold %.2f%% of the supply", topHolderAccounts, metrics.TopHolderShare))
+	}
+	builder.WriteString(".")
+
+	if len(metrics.LargeTransfers) > 0 {
+		transfers := append([]TokenTransfer(nil), metrics.LargeTransfers...)
+		sort.Slice(transfers, func(i, j int) bool {
+			return transfers[i].Amount > transfers[j].Amount
+		})
+
+		var lines []string
+		for _, transfer := range transfers {
+			line := fmt.Sprintf("%s moved from %s to %s", formatAmount(transfer.Amount), shortAddress(transfer.From), shortAddress(transfer.To))
+			if !transfer.Timestamp.IsZero() {
+				line += fmt.Sprintf(" %s ago", now.Sub(transfer.Timestamp).Round(time.Minute))
+			}
+			lines = append(lines, line)
+		}
+		builder.WriteString(" Recent large transfers: " + strings.Join(lines, "; ") + ".")
+	}
+
+	return builder.String()
+}
+
+func formatAmount(amount float64) string {
+	switch {
+	case amount >= 1e9:
+		return fmt.Sprintf("%.2fB", amount/1e9)
+	case amount >= 1e6:
+		return fmt.Sprintf("%.2fM", amount/1e6)
+	case amount >= 1e3:
+		return fmt.Sprintf("%.2fK", amount/1e3)
+	default:
+		return fmt.Sprintf("%.2f", amount)
+	}
+}
+
+// shortAddress keeps the first and last 4 characters of an address
+func shortAddress(address string) string {
+	if address == "" {
+		return "unknown"
+	}
+	if len(address) <= 8 {
+		return address
+	}
+	return address[:4] + "..." + address[len(address)-4:]
+}
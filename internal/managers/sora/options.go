@@ -0,0 +1,215 @@
+package sora_manager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/soralabs/hana/internal/marketdata"
+	"github.com/soralabs/zen/options"
+	"gorm.io/gorm"
+)
+
+// ValidateRequiredFields checks if all required fields, including the watchlist, are set
+func (s *SoraManager) ValidateRequiredFields() error {
+	if err := s.BaseManager.ValidateRequiredFields(); err != nil {
+		return err
+	}
+	if s.database == nil {
+		return fmt.Errorf("database is required")
+	}
+	if len(s.watchlist) == 0 {
+		return fmt.Errorf("watchlist requires at least one token")
+	}
+	for _, token := range s.watchlist {
+		if token.Mint == "" || token.Name == "" {
+			return fmt.Errorf("watchlist tokens require a mint and a name")
+		}
+		switch token.Role {
+		case TokenRoleOurs, TokenRoleEcosystem:
+		default:
+			return fmt.Errorf("token %s has unknown role %q", token.Name, token.Role)
+		}
+	}
+	if s.snapshotInterval <= 0 {
+		return fmt.Errorf("snapshot interval must be positive")
+	}
+	if s.alertConfig.PollInterval <= 0 {
+		return fmt.Errorf("alert poll interval must be positive")
+	}
+	if s.alertConfig.Cooldown <= 0 {
+		return fmt.Errorf("alert cooldown must be positive")
+	}
+	if s.alertConfig.LiquidityLookback <= 0 {
+		return fmt.Errorf("alert liquidity lookback must be positive")
+	}
+	// a threshold of zero would fire on every poll
+	if s.alertConfig.PriceMoveThreshold <= 0 || s.alertConfig.LiquidityDropThreshold <= 0 || s.alertConfig.VolumeSpikeMultiplier <= 0 {
+		return fmt.Errorf("alert price move, liquidity drop and volume spike thresholds must be positive")
+	}
+	for _, milestone := range s.alertConfig.MarketCapMilestones {
+		if milestone <= 0 {
+			return fmt.Errorf("alert market cap milestones must be positive")
+		}
+	}
+	if s.onchainConfig.RefreshInterval <= 0 || s.onchainConfig.TransferLookback <= 0 {
+		return fmt.Errorf("on-chain refresh interval and transfer lookback must be positive")
+	}
+	for _, wallet := range s.onchainConfig.NonCirculatingWallets {
+		if _, err := solana.PublicKeyFromBase58(wallet); err != nil {
+			return fmt.Errorf("invalid non-circulating wallet %s: %w", wallet, err)
+		}
+	}
+	if s.whaleConfig.PollInterval <= 0 || s.whaleConfig.BatchSize <= 0 || s.whaleConfig.ContextWindow <= 0 {
+		return fmt.Errorf("whale poll interval, batch size and context window must be positive")
+	}
+	if s.whaleConfig.ThresholdUsd <= 0 {
+		return fmt.Errorf("whale threshold must be positive")
+	}
+	for _, threshold := range s.holderTiers {
+		if threshold.Tier == "" || threshold.Tier == HolderTierNone || threshold.MinBalance <= 0 {
+			return fmt.Errorf("holder tiers require a name other than %q and a positive balance", HolderTierNone)
+		}
+	}
+	for _, wallet := range s.whaleConfig.KnownWallets {
+		if _, err := solana.PublicKeyFromBase58(wallet.Address); err != nil {
+			return fmt.Errorf("invalid known wallet %s: %w", wallet.Label, err)
+		}
+		if wallet.Label == "" {
+			return fmt.Errorf("known wallet %s requires a label", wallet.Address)
+		}
+		switch wallet.Kind {
+		case WalletKindTeam, WalletKindLP, WalletKindCEX:
+		default:
+			return fmt.Errorf("known wallet %s has unknown kind %q", wallet.Label, wallet.Kind)
+		}
+	}
+	return nil
+}
+
+// WithWatchlist sets the tokens the manager tracks, replacing the default watchlist
+func WithWatchlist(tokens ...WatchedToken) options.Option[SoraManager] {
+	return func(s *SoraManager) error {
+		s.watchlist = tokens
+		return nil
+	}
+}
+
+// WithMarketDataProvider sets where token market data is fetched from, the default chain when not set
+func WithMarketDataProvider(provider marketdata.MarketDataProvider) options.Option[SoraManager] {
+	return func(s *SoraManager) error {
+		s.marketData = provider
+		return nil
+	}
+}
+
+// WithSnapshotInterval sets how often market snapshots of the watched tokens are recorded
+func WithSnapshotInterval(interval time.Duration) options.Option[SoraManager] {
+	return func(s *SoraManager) error {
+		s.snapshotInterval = interval
+		return nil
+	}
+}
+
+// WithAlertConfig sets the market conditions that trigger alerts
+func WithAlertConfig(config AlertConfig) options.Option[SoraManager] {
+	return func(s *SoraManager) error {
+		s.alertConfig = config
+		return nil
+	}
+}
+
+// WithSolanaRPC enables the on-chain metrics of the Sora token, read through the given RPC client
+func WithSolanaRPC(client *rpc.Client) options.Option[SoraManager] {
+	return func(s *SoraManager) error {
+		s.solanaRPC = client
+		return nil
+	}
+}
+
+// WithDatabase sets the database wallet links are stored in
+func WithDatabase(database *gorm.DB) options.Option[SoraManager] {
+	return func(s *SoraManager) error {
+		s.database = database
+		return nil
+	}
+}
+
+// WithOnchainConfig sets how the on-chain metrics are computed
+func WithOnchainConfig(config OnchainConfig) options.Option[SoraManager] {
+	return func(s *SoraManager) error {
+		s.onchainConfig = config
+		return nil
+	}
+}
+
+// WithWhaleConfig sets how whale transfers of the Sora token are detected and labelled
+func WithWhaleConfig(config WhaleConfig) options.Option[SoraManager] {
+	return func(s *SoraManager) error {
+		s.whaleConfig = config
+		return nil
+	}
+}
+
+// WithHolderTiers sets the Sora balances actors are ranked by, replacing the default tiers
+func WithHolderTiers(tiers ...HolderTierThreshold) options.Option[SoraManager] {
+	return func(s *SoraManager) error {
+		s.holderTiers = tiers
+		return nil
+	}
+}
+
+// ParseWatchlist parses a comma separated list of name:mint[:role] entries.
+// The role defaults to ecosystem.
+func ParseWatchlist(value string) ([]WatchedToken, error) {
+	var tokens []WatchedToken
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid watchlist entry %q, expected name:mint[:role]", entry)
+		}
+
+		token := WatchedToken{
+			Name: strings.TrimSpace(parts[0]),
+			Mint: strings.TrimSpace(parts[1]),
+			Role: TokenRoleEcosystem,
+		}
+		if len(parts) == 3 {
+			token.Role = TokenRole(strings.TrimSpace(parts[2]))
+		}
+
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// ParseKnownWallets parses a comma separated list of label:kind:address entries,
+// kind is one of team, lp or cex
+func ParseKnownWallets(value string) ([]KnownWallet, error) {
+	var wallets []KnownWallet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid known wallet entry %q, expected label:kind:address", entry)
+		}
+
+		wallets = append(wallets, KnownWallet{
+			Label:   strings.TrimSpace(parts[0]),
+			Kind:    WalletKind(strings.TrimSpace(parts[1])),
+			Address: strings.TrimSpace(parts[2]),
+		})
+	}
+	return wallets, nil
+}
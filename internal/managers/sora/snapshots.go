@@ -0,0 +1,169 @@
+package sora_manager
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/stores"
+)
+
+// marketSessionID groups the market data fragments, snapshots and alerts, under a single static session
+var marketSessionID = id.FromString("sora_market_data")
+
+// snapshotLoop records a snapshot of every watched token on each interval until stopped
+func (s *SoraManager) snapshotLoop() {
+	ticker := time.NewTicker(s.snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		s.recordSnapshots()
+
+		select {
+		case <-s.Ctx.Done():
+			s.Logger.Infof("Token snapshots stopped")
+			return
+		case <-s.stopChan:
+			s.Logger.Infof("Token snapshots stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordSnapshots snapshots every watched token, tokens that fail are retried on the next interval
+func (s *SoraManager) recordSnapshots() {
+	for _, token := range s.watchlist {
+		snapshot, err := s.recordSnapshot(token)
+		if err != nil {
+			s.Logger.Warnf("failed to record snapshot for %s: %v", token.Name, err)
+			continue
+		}
+
+		s.Logger.WithFields(map[string]interface{}{
+			"token":     token.Name,
+			"price_usd": snapshot.PriceUsd,
+			"ath_usd":   snapshot.AthUsd,
+		}).Infof("Recorded token snapshot")
+	}
+}
+
+// recordSnapshot stores the token's current market data in the sora fragment table
+func (s *SoraManager) recordSnapshot(token WatchedToken) (*TokenSnapshot, error) {
+	market, err := s.getTokenMarket(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// trends and alerts compare volume and liquidity, a price only reading would skew them
+	if market.Activity == nil {
+		return nil, fmt.Errorf("%s reported no trading activity", market.Provider)
+	}
+
+	price := market.PriceUsd
+	snapshot := &TokenSnapshot{
+		Mint:         token.Mint,
+		Name:         token.Name,
+		Symbol:       market.Symbol,
+		PairAddress:  market.PairAddress,
+		PriceUsd:     price,
+		VolumeH24:    market.Activity.Volume.H24,
+		LiquidityUsd: market.Activity.LiquidityUsd,
+		MarketCap:    market.Activity.MarketCap,
+		Fdv:          market.Activity.Fdv,
+		AthUsd:       price,
+		Timestamp:    time.Now(),
+	}
+
+	// the all time high is carried forward so trends don't need the full history
+	previous, err := s.latestSnapshot(token)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		snapshot.AthUsd = math.Max(previous.AthUsd, price)
+	}
+
+	if err := s.Store(&db.Fragment{
+		ID:        id.New(),
+		ActorID:   s.AssistantID,
+		SessionID: marketSessionID,
+		Content: fmt.Sprintf("%s price %.6g USD, 24h volume %.2f USD, liquidity %.2f USD",
+			token.Name, price, snapshot.VolumeH24, snapshot.LiquidityUsd),
+		Embedding: pgvector.NewVector(make([]float32, 1536)),
+		Metadata: db.Metadata{
+			"type":          FragmentTypeTokenSnapshot,
+			"mint":          snapshot.Mint,
+			"name":          snapshot.Name,
+			"symbol":        snapshot.Symbol,
+			"pair_address":  snapshot.PairAddress,
+			"price_usd":     snapshot.PriceUsd,
+			"volume_h24":    snapshot.VolumeH24,
+			"liquidity_usd": snapshot.LiquidityUsd,
+			"market_cap":    snapshot.MarketCap,
+			"fdv":           snapshot.Fdv,
+			"ath_usd":       snapshot.AthUsd,
+		},
+		CreatedAt: snapshot.Timestamp,
+		UpdatedAt: snapshot.Timestamp,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	s.Cache.Delete(tokenTrendsCacheKey(token))
+
+	return snapshot, nil
+}
+
+// latestSnapshot returns the token's most recent snapshot, or nil if it has none
+func (s *SoraManager) latestSnapshot(token WatchedToken) (*TokenSnapshot, error) {
+	snapshots, err := s.searchSnapshots(token, nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	return &snapshots[0], nil
+}
+
+// searchSnapshots returns the token's snapshots since the given time, newest first
+func (s *SoraManager) searchSnapshots(token WatchedToken, since *time.Time, limit int) ([]TokenSnapshot, error) {
+	fragments, err := s.FragmentStore.SearchByFilter(stores.FragmentFilter{
+		SessionID: &marketSessionID,
+		Metadata: []stores.MetadataCondition{
+			{Key: "type", Value: FragmentTypeTokenSnapshot, Operator: stores.MetadataOpEquals},
+			{Key: "mint", Value: token.Mint, Operator: stores.MetadataOpEquals},
+		},
+		StartTime: since,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search snapshots: %w", err)
+	}
+
+	snapshots := make([]TokenSnapshot, 0, len(fragments))
+	for _, fragment := range fragments {
+		snapshots = append(snapshots, snapshotFromFragment(fragment))
+	}
+
+	return snapshots, nil
+}
+
+func snapshotFromFragment(fragment db.Fragment) TokenSnapshot {
+	return TokenSnapshot{
+		Mint:         fragment.Metadata.GetString("mint"),
+		Name:         fragment.Metadata.GetString("name"),
+		Symbol:       fragment.Metadata.GetString("symbol"),
+		PairAddress:  fragment.Metadata.GetString("pair_address"),
+		PriceUsd:     fragment.Metadata.GetFloat("price_usd"),
+		VolumeH24:    fragment.Metadata.GetFloat("volume_h24"),
+		LiquidityUsd: fragment.Metadata.GetFloat("liquidity_usd"),
+		MarketCap:    fragment.Metadata.GetFloat("market_cap"),
+		Fdv:          fragment.Metadata.GetFloat("fdv"),
+		AthUsd:       fragment.Metadata.GetFloat("ath_usd"),
+		Timestamp:    fragment.CreatedAt,
+	}
+}
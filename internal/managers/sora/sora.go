@@ -1,9 +1,11 @@
 package sora_manager
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
-	"github.com/soralabs/zen/cache"
+	"github.com/soralabs/hana/internal/marketdata"
 	"github.com/soralabs/zen/db"
 	"github.com/soralabs/zen/manager"
 	"github.com/soralabs/zen/options"
@@ -12,6 +14,7 @@ import (
 
 func NewSoraManager(
 	baseOpts []options.Option[manager.BaseManager],
+	soraOpts ...options.Option[SoraManager],
 ) (*SoraManager, error) {
 	base, err := manager.NewBaseManager(baseOpts...)
 	if err != nil {
@@ -19,18 +22,33 @@ func NewSoraManager(
 	}
 
 	pm := &SoraManager{
-		BaseManager: base,
-		cache: cache.New(cache.Config{
-			MaxSize:       1000,
-			TTL:           1 * time.Minute,
-			CleanupPeriod: 1 * time.Minute,
-		}),
+		BaseManager:      base,
+		watchlist:        DefaultWatchlist,
+		snapshotInterval: DefaultSnapshotInterval,
+		alertConfig:      DefaultAlertConfig,
+		alerts:           make(chan MarketEvent, alertQueueSize),
+		onchainConfig:    DefaultOnchainConfig,
+		whaleConfig:      DefaultWhaleConfig,
+		holderTiers:      DefaultHolderTiers,
+		stopChan:         make(chan struct{}),
 	}
 
-	if err := options.ApplyOptions(pm); err != nil {
+	if err := options.ApplyOptions(pm, soraOpts...); err != nil {
 		return nil, err
 	}
 
+	// the default chain starts cache cleanup goroutines, it is only built when no provider was injected
+	if pm.marketData == nil {
+		pm.marketData, err = marketdata.NewDefaultChain()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create market data provider: %w", err)
+		}
+	}
+
+	if err := pm.database.AutoMigrate(&ActorWallet{}, &WalletLinkNonce{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate actor wallets: %w", err)
+	}
+
 	return pm, nil
 }
 
@@ -75,32 +93,123 @@ Hana serves as both a practical demonstration of Zen's capabilities and a bridge
 
 	returnData := []state.StateData{soraInfo}
 
-	soraTokenData, err := s.getSoraTokenData()
-	if err == nil {
+	// each watched token gets its own key, tokens that fail to load are left out
+	var summaries []string
+	for _, token := range s.watchlist {
+		tokenData, err := s.getTokenData(token)
+		if err != nil {
+			s.Logger.Warnf("failed to get token data for %s: %v", token.Name, err)
+			continue
+		}
+
+		returnData = append(returnData, state.StateData{
+			Key:   TokenDataKey(token),
+			Value: tokenData,
+		})
+		if token.Role == TokenRoleOurs {
+			returnData = append(returnData, state.StateData{
+				Key:   SoraTokenData,
+				Value: tokenData,
+			})
+		}
+		summaries = append(summaries, tokenData)
+	}
+
+	if len(summaries) > 0 {
 		returnData = append(returnData, state.StateData{
-			Key:   SoraTokenData,
-			Value: soraTokenData,
+			Key:   WatchlistTokenData,
+			Value: strings.Join(summaries, "\n"),
 		})
 	}
 
+	returnData = append(returnData, s.trendsContext()...)
+
+	// on-chain metrics are refreshed in the background, they are left out until the first refresh
+	if metrics := s.getOnchainMetrics(); metrics != nil {
+		returnData = append(returnData, state.StateData{
+			Key:   SoraOnchainData,
+			Value: summarizeOnchainMetrics(metrics, time.Now()),
+		})
+	}
+
+	if s.solanaRPC != nil {
+		whaleActivity, err := s.whaleActivity(time.Now())
+		if err != nil {
+			s.Logger.Warnf("failed to get whale activity: %v", err)
+		} else if whaleActivity != "" {
+			returnData = append(returnData, state.StateData{
+				Key:   SoraWhaleActivity,
+				Value: whaleActivity,
+			})
+		}
+	}
+
 	return returnData, nil
 }
 
-// Store persists a message fragment to storage
-// Currently unimplemented as Sora configuration is static
+// trendsContext returns the price history summaries of the watched tokens that have snapshots
+func (s *SoraManager) trendsContext() []state.StateData {
+	var returnData []state.StateData
+	var trends []string
+	for _, token := range s.watchlist {
+		tokenTrends, err := s.getTokenTrends(token)
+		if err != nil {
+			s.Logger.Warnf("failed to get token trends for %s: %v", token.Name, err)
+			continue
+		}
+		if tokenTrends == "" {
+			continue
+		}
+
+		returnData = append(returnData, state.StateData{
+			Key:   TokenTrendsKey(token),
+			Value: tokenTrends,
+		})
+		if token.Role == TokenRoleOurs {
+			returnData = append(returnData, state.StateData{
+				Key:   SoraTokenTrends,
+				Value: tokenTrends,
+			})
+		}
+		trends = append(trends, tokenTrends)
+	}
+
+	if len(trends) > 0 {
+		returnData = append(returnData, state.StateData{
+			Key:   WatchlistTokenTrends,
+			Value: strings.Join(trends, "\n"),
+		})
+	}
+
+	return returnData
+}
+
+// Store persists a token snapshot fragment to the sora fragment table
 func (s *SoraManager) Store(fragment *db.Fragment) error {
-	// Implement
-	return nil
+	return s.FragmentStore.Create(fragment)
 }
 
-// StartBackgroundProcesses initializes any background tasks
-// Currently unimplemented as Sora configuration is static
+// StartBackgroundProcesses records market snapshots of the watched tokens, checks them
+// for alerts and, when an RPC client is set, refreshes the on-chain metrics and watches
+// for whale transfers, each on its own interval.
+// It blocks until the manager is stopped, the engine runs it in its own goroutine.
 func (s *SoraManager) StartBackgroundProcesses() {
-	// Implement
+	if err := s.SessionStore.Upsert(&db.Session{ID: marketSessionID}); err != nil {
+		s.Logger.Errorf("failed to upsert market data session: %v", err)
+		return
+	}
+
+	go s.alertLoop()
+	if s.solanaRPC != nil {
+		go s.onchainLoop()
+		go s.whaleLoop()
+	}
+	s.snapshotLoop()
 }
 
-// StopBackgroundProcesses cleanly shuts down any background tasks
-// Currently unimplemented as no background processes exist
+// StopBackgroundProcesses stops every background loop
 func (s *SoraManager) StopBackgroundProcesses() {
-	// Implement
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
 }
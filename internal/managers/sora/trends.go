@@ -0,0 +1,146 @@
+package sora_manager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/soralabs/zen/cache"
+)
+
+// trendWindow is a period the price history is summarized over
+type trendWindow struct {
+	label    string
+	duration time.Duration
+}
+
+var trendWindows = []trendWindow{
+	{label: "24h", duration: 24 * time.Hour},
+	{label: "7d", duration: 7 * 24 * time.Hour},
+	{label: "30d", duration: 30 * 24 * time.Hour},
+}
+
+// volumeTrendThreshold is the change in average volume below which volume counts as flat
+const volumeTrendThreshold = 10.0
+
+// getTokenTrends summarizes the recorded snapshots of a token over each trend window.
+// Returns an empty summary when nothing has been recorded yet.
+func (s *SoraManager) getTokenTrends(token WatchedToken) (string, error) {
+	// trends only move when a snapshot is recorded, which clears the cached summary
+	cacheKey := tokenTrendsCacheKey(token)
+	if cached, exists := s.Cache.Get(cacheKey); exists {
+		return cached.(string), nil
+	}
+
+	longest := trendWindows[len(trendWindows)-1].duration
+	since := time.Now().Add(-longest)
+	snapshots, err := s.searchSnapshots(token, &since, 0)
+	if err != nil {
+		return "", err
+	}
+
+	summary := summarizeTrends(token, snapshots, time.Now())
+
+	s.Cache.Set(cacheKey, summary)
+
+	return summary, nil
+}
+
+// summarizeTrends formats the trends of snapshots ordered newest first
+func summarizeTrends(token WatchedToken, snapshots []TokenSnapshot, now time.Time) string {
+	if len(snapshots) == 0 {
+		return ""
+	}
+
+	latest := snapshots[0]
+
+	var windows []string
+	for _, window := range trendWindows {
+		windows = append(windows, fmt.Sprintf("%s: %s", window.label, windowTrend(snapshots, now.Add(-window.duration))))
+	}
+
+	summary := fmt.Sprintf("%s price history: %s.", token.Name, strings.Join(windows, "; "))
+	if latest.AthUsd > 0 {
+		summary += fmt.Sprintf(" All-time high since tracking began: %.6g USD, currently %.2f%% below it.",
+			latest.AthUsd, percentBelow(latest.PriceUsd, latest.AthUsd))
+	}
+
+	return summary
+}
+
+// windowTrend describes the price change, high, drawdown and volume trend of the snapshots after start
+func windowTrend(snapshots []TokenSnapshot, start time.Time) string {
+	var inWindow []TokenSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Timestamp.Before(start) {
+			break
+		}
+		inWindow = append(inWindow, snapshot)
+	}
+
+	if len(inWindow) < 2 {
+		return "not enough history"
+	}
+
+	current := inWindow[0]
+	oldest := inWindow[len(inWindow)-1]
+
+	high := current.PriceUsd
+	for _, snapshot := range inWindow {
+		if snapshot.PriceUsd > high {
+			high = snapshot.PriceUsd
+		}
+	}
+
+	change := 0.0
+	if oldest.PriceUsd > 0 {
+		change = (current.PriceUsd - oldest.PriceUsd) / oldest.PriceUsd * 100
+	}
+
+	return fmt.Sprintf("%+.2f%%, high %.6g USD, %.2f%% below high, volume %s",
+		change, high, percentBelow(current.PriceUsd, high), volumeTrend(inWindow))
+}
+
+// volumeTrend compares the average 24h volume of the newer half of the snapshots to the older half
+func volumeTrend(snapshots []TokenSnapshot) string {
+	middle := len(snapshots) / 2
+	newer := averageVolume(snapshots[:middle])
+	older := averageVolume(snapshots[middle:])
+
+	if older == 0 {
+		return "flat"
+	}
+
+	change := (newer - older) / older * 100
+	switch {
+	case change >= volumeTrendThreshold:
+		return fmt.Sprintf("rising (%+.2f%%)", change)
+	case change <= -volumeTrendThreshold:
+		return fmt.Sprintf("falling (%+.2f%%)", change)
+	default:
+		return "flat"
+	}
+}
+
+func averageVolume(snapshots []TokenSnapshot) float64 {
+	if len(snapshots) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, snapshot := range snapshots {
+		total += snapshot.VolumeH24
+	}
+	return total / float64(len(snapshots))
+}
+
+func percentBelow(price, high float64) float64 {
+	if high <= 0 || price >= high {
+		return 0
+	}
+	return (high - price) / high * 100
+}
+
+func tokenTrendsCacheKey(token WatchedToken) cache.CacheKey {
+	return cache.CacheKey(fmt.Sprintf("token_trends_%s", token.Mint))
+}
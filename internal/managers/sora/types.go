@@ -1,9 +1,17 @@
 package sora_manager
 
 import (
-	"github.com/soralabs/zen/cache"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/soralabs/hana/internal/marketdata"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
 	"github.com/soralabs/zen/manager"
 	"github.com/soralabs/zen/options"
+	"gorm.io/gorm"
 )
 
 // SoraManager handles Sora-specific behavior and responses, such as information about Sora
@@ -11,5 +19,225 @@ type SoraManager struct {
 	*manager.BaseManager
 	options.RequiredFields
 
-	cache *cache.Cache
+	marketData marketdata.MarketDataProvider
+	solanaRPC  *rpc.Client
+	database   *gorm.DB
+	watchlist  []WatchedToken
+
+	snapshotInterval time.Duration
+	alertConfig      AlertConfig
+	alerts           chan MarketEvent
+	onchainConfig    OnchainConfig
+	whaleConfig      WhaleConfig
+	holderTiers      []HolderTierThreshold
+	stopChan         chan struct{}
+	stopOnce         sync.Once
+
+	// onchainMetrics is the last successful on-chain refresh
+	onchainMetrics   *OnchainMetrics
+	onchainMetricsMu sync.RWMutex
+
+	// whaleCursor is the newest transaction the whale watcher has fully processed, only used by whaleLoop
+	whaleCursor solana.Signature
+}
+
+// TokenRole describes how a watched token relates to Sora
+type TokenRole string
+
+// WatchedToken is a token whose market data is provided as context
+type WatchedToken struct {
+	Mint string
+	Name string
+	Role TokenRole
+}
+
+// TokenSnapshot is a point in time recording of a token's market data
+type TokenSnapshot struct {
+	Mint         string
+	Name         string
+	Symbol       string
+	PairAddress  string
+	PriceUsd     float64
+	VolumeH24    float64
+	LiquidityUsd float64
+	MarketCap    float64
+	Fdv          float64
+	// AthUsd is the highest price recorded since tracking began
+	AthUsd    float64
+	Timestamp time.Time
+}
+
+// AlertType identifies the market condition an alert fired for
+type AlertType string
+
+// AlertConfig holds the market conditions that trigger alerts
+type AlertConfig struct {
+	// PollInterval is how often the watched tokens are checked
+	PollInterval time.Duration
+	// Roles are the token roles alerts are raised for
+	Roles []TokenRole
+	// PriceMoveThreshold is the 1h price change in percent, up or down
+	PriceMoveThreshold float64
+	// LiquidityDropThreshold is the liquidity drop in percent compared to LiquidityLookback ago
+	LiquidityDropThreshold float64
+	LiquidityLookback      time.Duration
+	// VolumeSpikeMultiplier is how many times the average hourly volume of the last 24h
+	// the last hour's volume has to reach
+	VolumeSpikeMultiplier float64
+	// MarketCapMilestones are market caps in USD that alert when crossed upwards
+	MarketCapMilestones []float64
+	// Cooldown is how long the same event is ignored after it fired
+	Cooldown time.Duration
+}
+
+// MarketEvent is a fired alert
+type MarketEvent struct {
+	Type  AlertType
+	Token WatchedToken
+	// Key identifies the event for cooldowns
+	Key         string
+	Description string
+	Timestamp   time.Time
+}
+
+// OnchainConfig holds how on-chain metrics of the Sora token are computed
+type OnchainConfig struct {
+	// RefreshInterval is how often the metrics are recomputed
+	RefreshInterval time.Duration
+	// NonCirculatingWallets are owners whose balances don't count towards the circulating supply,
+	// such as team, treasury or locked wallets
+	NonCirculatingWallets []string
+	// LargeTransferShare is the share of the total supply in percent a transfer has to move to count as large
+	LargeTransferShare float64
+	// TransferLookback is how many of the mint's latest transactions are scanned for large transfers
+	TransferLookback int
+}
+
+// OnchainMetrics are the supply and holder statistics of a token
+type OnchainMetrics struct {
+	Mint              string
+	Symbol            string
+	TotalSupply       float64
+	CirculatingSupply float64
+	// HolderCount is zero when the RPC node doesn't allow counting token accounts
+	HolderCount int
+	// TopHolderShare is the share of the total supply held by the largest token accounts in percent
+	TopHolderShare float64
+	LargeTransfers []TokenTransfer
+	UpdatedAt      time.Time
+}
+
+// TokenTransfer is the largest movement of a token within a transaction
+type TokenTransfer struct {
+	Signature string
+	// From and To are the owners of the token accounts that lost and gained the most
+	From      string
+	To        string
+	Amount    float64
+	Timestamp time.Time
+}
+
+// WalletKind describes who a known wallet belongs to
+type WalletKind string
+
+// KnownWallet is a wallet whose transfers are labelled
+type KnownWallet struct {
+	// Address is the owner address, not a token account
+	Address string
+	Label   string
+	Kind    WalletKind
+}
+
+// WhaleDirection describes a whale transfer from the point of view of the market
+type WhaleDirection string
+
+// WhaleConfig holds how large transfers of the Sora token are detected
+type WhaleConfig struct {
+	// PollInterval is how often the mint's new transactions are scanned
+	PollInterval time.Duration
+	// ThresholdUsd is the value a transfer has to move to count as a whale transfer
+	ThresholdUsd float64
+	// BatchSize is the most transactions scanned per poll
+	BatchSize int
+	// ContextWindow is how far back whale transfers are provided as context
+	ContextWindow time.Duration
+	// KnownWallets label team, liquidity pool and exchange wallets
+	KnownWallets []KnownWallet
+}
+
+// WhaleTransfer is a transfer above the whale threshold
+type WhaleTransfer struct {
+	TokenTransfer
+	AmountUsd float64
+	// FromLabel and ToLabel are empty for unknown wallets
+	FromLabel string
+	ToLabel   string
+	Direction WhaleDirection
+}
+
+// HolderTier ranks an actor by the Sora balance of their linked wallet
+type HolderTier string
+
+// HolderTierThreshold is the smallest Sora balance of a tier
+type HolderTierThreshold struct {
+	Tier       HolderTier
+	MinBalance float64
+}
+
+// WalletLinkMethod is how an actor proved a wallet is theirs
+type WalletLinkMethod string
+
+// WalletLink is a wallet an actor linked to themselves
+type WalletLink struct {
+	Wallet string
+	Method WalletLinkMethod
+	// Verified is set for links proven with a signed message
+	Verified bool
+}
+
+// ActorWallet is the wallet linked to an actor, stored next to the actor record in the actor_wallets table.
+// A wallet is linked to at most one actor.
+type ActorWallet struct {
+	ActorID  id.ID            `gorm:"type:uuid;primaryKey"`
+	Actor    *db.Actor        `gorm:"constraint:OnDelete:CASCADE"`
+	Wallet   string           `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Method   WalletLinkMethod `gorm:"type:varchar(16);not null"`
+	Verified bool             `gorm:"not null;default:false"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName keeps wallet links in the actor_wallets table
+func (ActorWallet) TableName() string {
+	return "actor_wallets"
+}
+
+// WalletLinkProof is a link challenge signed with the wallet
+type WalletLinkProof struct {
+	Username string
+	Wallet   string
+	Nonce    string
+	IssuedAt time.Time
+	// Signature is the base58 encoded signature of the challenge
+	Signature string
+}
+
+// WalletLinkNonce is a nonce used by a signed wallet link, kept in the wallet_link_nonces table so it can't be replayed
+type WalletLinkNonce struct {
+	Nonce     string `gorm:"type:varchar(64);primaryKey"`
+	ActorID   id.ID  `gorm:"type:uuid;not null"`
+	CreatedAt time.Time
+}
+
+// TableName keeps used nonces in the wallet_link_nonces table
+func (WalletLinkNonce) TableName() string {
+	return "wallet_link_nonces"
+}
+
+// HolderStatus is the holder tier of an actor with a linked wallet
+type HolderStatus struct {
+	WalletLink
+	Balance float64
+	Tier    HolderTier
 }
@@ -0,0 +1,283 @@
+package sora_manager
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/soralabs/zen/cache"
+)
+
+// walletAddressRegex matches base58 words the length of a Solana address
+var walletAddressRegex = regexp.MustCompile(`\b[1-9A-HJ-NP-Za-km-z]{32,44}\b`)
+
+// MaxWalletLookups is how many addresses of a single message are resolved at most.
+// Telling a wallet apart from a token takes an RPC call, the guardrails and the wallet lookups share the limit.
+const MaxWalletLookups = 3
+
+const (
+	// walletActivityLimit is how many of a wallet's latest transactions are counted
+	walletActivityLimit = 100
+)
+
+// WalletSummary is the on-chain data of a wallet someone asked about
+type WalletSummary struct {
+	Address     string
+	SolBalance  float64
+	SoraBalance float64
+	// SoraValueUsd is zero when the Sora price is unavailable
+	SoraValueUsd float64
+	// SoraSupplyShare is in percent, zero before the on-chain metrics are refreshed
+	SoraSupplyShare float64
+	// OtherTokens counts the other tokens held, they are never named
+	OtherTokens int
+	// TransactionsH24 and TransactionsD7 count up to walletActivityLimit transactions
+	TransactionsH24 int
+	TransactionsD7  int
+	// LastActive is zero for wallets without transactions
+	LastActive time.Time
+}
+
+// isWallet reports whether the address is a wallet rather than a token mint, program or other account.
+// Addresses without an account count as wallets since they can't be tokens.
+func (s *SoraManager) isWallet(address string) bool {
+	if s.solanaRPC == nil {
+		return false
+	}
+	for _, token := range s.watchlist {
+		if token.Mint == address {
+			return false
+		}
+	}
+
+	publicKey, err := solana.PublicKeyFromBase58(address)
+	if err != nil {
+		return false
+	}
+
+	cacheKey := cache.CacheKey(fmt.Sprintf("is_wallet_%s", address))
+	if cached, exists := s.Cache.Get(cacheKey); exists {
+		return cached.(bool)
+	}
+
+	isWallet := true
+	account, err := s.solanaRPC.GetAccountInfo(s.Ctx, publicKey)
+	switch {
+	case errors.Is(err, rpc.ErrNotFound):
+	case err != nil:
+		s.Logger.Warnf("failed to get account %s: %v", address, err)
+		return false
+	default:
+		isWallet = account.Value.Owner.Equals(solana.SystemProgramID)
+	}
+
+	s.Cache.Set(cacheKey, isWallet)
+
+	return isWallet
+}
+
+// FindWallets returns the wallets among the first MaxWalletLookups distinct addresses of a text,
+// in order of appearance. Addresses that aren't wallets, such as token mints, are skipped.
+func (s *SoraManager) FindWallets(content string) []string {
+	var wallets []string
+	for _, address := range walletCandidates(content) {
+		if s.isWallet(address) {
+			wallets = append(wallets, address)
+		}
+	}
+	return wallets
+}
+
+// walletCandidates returns the first MaxWalletLookups distinct Solana addresses of a text
+func walletCandidates(content string) []string {
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, address := range walletAddressRegex.FindAllString(content, -1) {
+		if len(candidates) == MaxWalletLookups {
+			break
+		}
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+
+		// base58 words that don't decode to a public key aren't worth an RPC call
+		if _, err := solana.PublicKeyFromBase58(address); err == nil {
+			candidates = append(candidates, address)
+		}
+	}
+	return candidates
+}
+
+// WalletLookups summarizes the wallets mentioned in a message for prompts.
+// Returns an empty string when the message mentions no wallets.
+func (s *SoraManager) WalletLookups(content string) string {
+	var summaries []string
+	for _, address := range s.FindWallets(content) {
+		summary, err := s.getWalletSummary(address)
+		if err != nil {
+			s.Logger.Warnf("failed to look up wallet %s: %v", address, err)
+			continue
+		}
+		summaries = append(summaries, summarizeWallet(summary, time.Now()))
+	}
+
+	return strings.Join(summaries, "\n")
+}
+
+// getWalletSummary returns the cached summary of a wallet, looking it up when missing
+func (s *SoraManager) getWalletSummary(address string) (*WalletSummary, error) {
+	cacheKey := cache.CacheKey(fmt.Sprintf("wallet_summary_%s", address))
+	if cached, exists := s.Cache.Get(cacheKey); exists {
+		return cached.(*WalletSummary), nil
+	}
+
+	summary, err := s.lookupWallet(s.Ctx, solana.MustPublicKeyFromBase58(address))
+	if err != nil {
+		return nil, err
+	}
+
+	s.Cache.Set(cacheKey, summary)
+
+	return summary, nil
+}
+
+// lookupWallet reads the SOL balance, Sora holdings, token count and recent activity of a wallet
+func (s *SoraManager) lookupWallet(ctx context.Context, wallet solana.PublicKey) (*WalletSummary, error) {
+	token := s.soraToken()
+	mint := solana.MustPublicKeyFromBase58(token.Mint)
+
+	balance, err := s.solanaRPC.GetBalance(ctx, wallet, rpc.CommitmentConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SOL balance: %w", err)
+	}
+
+	summary := &WalletSummary{
+		Address:    wallet.String(),
+		SolBalance: float64(balance.Value) / float64(solana.LAMPORTS_PER_SOL),
+	}
+
+	if summary.SoraBalance, err = s.walletTokenBalance(ctx, wallet, mint); err != nil {
+		return nil, err
+	}
+	if summary.SoraBalance > 0 {
+		if market, err := s.getTokenMarket(token); err != nil {
+			s.Logger.Warnf("failed to price wallet holdings: %v", err)
+		} else {
+			summary.SoraValueUsd = summary.SoraBalance * market.PriceUsd
+		}
+		if metrics := s.getOnchainMetrics(); metrics != nil && metrics.TotalSupply > 0 {
+			summary.SoraSupplyShare = summary.SoraBalance / metrics.TotalSupply * 100
+		}
+	}
+
+	if summary.OtherTokens, err = s.countOtherTokens(ctx, wallet, mint); err != nil {
+		return nil, err
+	}
+
+	limit := walletActivityLimit
+	signatures, err := s.solanaRPC.GetSignaturesForAddressWithOpts(ctx, wallet, &rpc.GetSignaturesForAddressOpts{
+		Limit:      &limit,
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet activity: %w", err)
+	}
+
+	now := time.Now()
+	for _, signature := range signatures {
+		if signature.BlockTime == nil {
+			continue
+		}
+		blockTime := signature.BlockTime.Time()
+		if summary.LastActive.IsZero() {
+			summary.LastActive = blockTime
+		}
+		if now.Sub(blockTime) <= 24*time.Hour {
+			summary.TransactionsH24++
+		}
+		if now.Sub(blockTime) <= 7*24*time.Hour {
+			summary.TransactionsD7++
+		}
+	}
+
+	return summary, nil
+}
+
+// countOtherTokens counts the wallet's token accounts of other mints with a non zero balance
+func (s *SoraManager) countOtherTokens(ctx context.Context, wallet, mint solana.PublicKey) (int, error) {
+	// the mint and the amount are the only fields needed, the owner sits between them
+	offset, length := uint64(0), uint64(72)
+	accounts, err := s.solanaRPC.GetTokenAccountsByOwner(ctx, wallet,
+		&rpc.GetTokenAccountsConfig{ProgramId: &solana.TokenProgramID},
+		&rpc.GetTokenAccountsOpts{
+			Commitment: rpc.CommitmentConfirmed,
+			Encoding:   solana.EncodingBase64,
+			DataSlice:  &rpc.DataSlice{Offset: &offset, Length: &length},
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get token accounts: %w", err)
+	}
+
+	tokens := 0
+	for _, account := range accounts.Value {
+		data := account.Account.Data.GetBinary()
+		if len(data) < 72 {
+			continue
+		}
+		if solana.PublicKeyFromBytes(data[:32]).Equals(mint) {
+			continue
+		}
+		if binary.LittleEndian.Uint64(data[64:72]) > 0 {
+			tokens++
+		}
+	}
+
+	return tokens, nil
+}
+
+// summarizeWallet formats a wallet summary for prompts.
+// The address is shortened and other tokens are only counted so replies never echo their contract addresses.
+func summarizeWallet(summary *WalletSummary, now time.Time) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet %s: %.4g SOL", shortAddress(summary.Address), summary.SolBalance))
+
+	if summary.SoraBalance > 0 {
+		builder.WriteString(fmt.Sprintf(", holds %s Sora", formatAmount(summary.SoraBalance)))
+		if summary.SoraValueUsd > 0 {
+			builder.WriteString(fmt.Sprintf(" worth %.2f USD", summary.SoraValueUsd))
+		}
+		if summary.SoraSupplyShare > 0 {
+			builder.WriteString(fmt.Sprintf(" (%.4f%% of the supply)", summary.SoraSupplyShare))
+		}
+	} else {
+		builder.WriteString(", holds no Sora")
+	}
+
+	builder.WriteString(fmt.Sprintf(", %d other tokens", summary.OtherTokens))
+
+	if summary.LastActive.IsZero() {
+		builder.WriteString(", no transactions yet.")
+		return builder.String()
+	}
+
+	builder.WriteString(fmt.Sprintf(", %s transactions in the last 24h and %s in the last 7d, last active %s ago.",
+		activityCount(summary.TransactionsH24), activityCount(summary.TransactionsD7), now.Sub(summary.LastActive).Round(time.Minute)))
+
+	return builder.String()
+}
+
+// activityCount marks counts that hit the activity limit as a lower bound
+func activityCount(count int) string {
+	if count >= walletActivityLimit {
+		return fmt.Sprintf("%d+", walletActivityLimit)
+	}
+	return fmt.Sprintf("%d", count)
+}
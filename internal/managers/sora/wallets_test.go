@@ -0,0 +1,50 @@
+package sora_manager
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestWalletCandidates(t *testing.T) {
+	addresses := make([]string, MaxWalletLookups+1)
+	for i := range addresses {
+		addresses[i] = solana.NewWallet().PublicKey().String()
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "no address",
+			content: "gm, how is the chart looking",
+		},
+		{
+			name:    "repeated address",
+			content: "check " + addresses[0] + " and again " + addresses[0],
+			want:    addresses[:1],
+		},
+		{
+			name:    "base58 word that isn't a key",
+			content: "1111111111111111111111111111111111111111111 " + addresses[0],
+			want:    addresses[:1],
+		},
+		{
+			name:    "more addresses than the limit",
+			content: "roast " + strings.Join(addresses, " "),
+			want:    addresses[:MaxWalletLookups],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := walletCandidates(tt.content); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package sora_manager
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/pgvector/pgvector-go"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/stores"
+)
+
+// whaleContextLimit is how many whale transfers are provided as context at most
+const whaleContextLimit = 10
+
+// whaleLoop scans the Sora mint's new transactions for whale transfers on each poll interval until stopped
+func (s *SoraManager) whaleLoop() {
+	ticker := time.NewTicker(s.whaleConfig.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.checkWhaleTransfers()
+
+		select {
+		case <-s.Ctx.Done():
+			s.Logger.Infof("Whale watcher stopped")
+			return
+		case <-s.stopChan:
+			s.Logger.Infof("Whale watcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkWhaleTransfers records the transfers above the threshold since the last scan, oldest first
+// and at most a batch per poll, so a backlog is worked off over the next polls instead of skipped.
+// The recent ones raise a single market event about the largest of them, under the token's whale cooldown.
+// The cursor only moves past transactions whose transfers were priced and recorded.
+func (s *SoraManager) checkWhaleTransfers() {
+	token := s.soraToken()
+	mint := solana.MustPublicKeyFromBase58(token.Mint)
+
+	var (
+		signatures []*rpc.TransactionSignature
+		err        error
+	)
+	if s.whaleCursor.IsZero() {
+		// the first scan starts at the latest batch, older history is never alerted on anyway
+		limit := s.whaleConfig.BatchSize
+		signatures, err = s.solanaRPC.GetSignaturesForAddressWithOpts(s.Ctx, mint, &rpc.GetSignaturesForAddressOpts{
+			Limit:      &limit,
+			Commitment: rpc.CommitmentFinalized,
+		})
+	} else {
+		signatures, err = s.signaturesSince(s.Ctx, mint, s.whaleCursor)
+	}
+	if err != nil {
+		s.Logger.Warnf("failed to scan whale transfers: %v", err)
+		return
+	}
+
+	slices.Reverse(signatures)
+	signatures = signatures[:min(len(signatures), s.whaleConfig.BatchSize)]
+
+	transfers, read, err := s.signatureTransfers(s.Ctx, mint, signatures)
+	if err != nil {
+		// the transactions read before the failure are still checked, the rest is retried
+		s.Logger.Warnf("failed to scan whale transfers: %v", err)
+	}
+
+	now := time.Now()
+	var recent []WhaleTransfer
+	if len(transfers) > 0 {
+		market, err := s.getTokenMarket(token)
+		if err != nil {
+			s.Logger.Warnf("failed to price whale transfers: %v", err)
+			return
+		}
+
+		for _, transfer := range transfers {
+			amountUsd := transfer.Amount * market.PriceUsd
+			if amountUsd < s.whaleConfig.ThresholdUsd {
+				continue
+			}
+
+			whale := s.labelTransfer(transfer, amountUsd)
+			recorded, err := s.recordWhaleTransfer(token, whale)
+			if err != nil {
+				s.Logger.Warnf("failed to record whale transfer %s: %v", whale.Signature, err)
+				// the scan resumes at this transfer on the next poll
+				read = slices.IndexFunc(signatures, func(signature *rpc.TransactionSignature) bool {
+					return signature.Signature.String() == transfer.Signature
+				})
+				break
+			}
+			// older transfers, like the ones found on the first scan, are only recorded
+			if recorded && (whale.Timestamp.IsZero() || now.Sub(whale.Timestamp) <= whaleEventMaxAge) {
+				recent = append(recent, whale)
+			}
+		}
+	}
+
+	if read > 0 {
+		s.whaleCursor = signatures[read-1].Signature
+	}
+
+	if len(recent) == 0 {
+		return
+	}
+	if err := s.fireEvent(whaleEvent(token, recent, now)); err != nil {
+		s.Logger.Warnf("failed to fire whale alert for %s: %v", token.Name, err)
+	}
+}
+
+// whaleEvent is the market event about the largest of the transfers.
+// Its key is the same for every whale alert of the token, so they share one cooldown.
+func whaleEvent(token WatchedToken, transfers []WhaleTransfer, now time.Time) MarketEvent {
+	largest := transfers[0]
+	for _, transfer := range transfers[1:] {
+		if transfer.AmountUsd > largest.AmountUsd {
+			largest = transfer
+		}
+	}
+
+	description := describeWhaleTransfer(token, largest)
+	if len(transfers) > 1 {
+		description = fmt.Sprintf("%s, the largest of %d whale transfers since the last check", description, len(transfers))
+	}
+
+	return MarketEvent{
+		Type:        AlertWhaleTransfer,
+		Token:       token,
+		Key:         fmt.Sprintf("%s_%s", token.Mint, AlertWhaleTransfer),
+		Description: description,
+		Timestamp:   now,
+	}
+}
+
+// labelTransfer names the known wallets of a transfer and tells its direction from their kinds
+func (s *SoraManager) labelTransfer(transfer TokenTransfer, amountUsd float64) WhaleTransfer {
+	whale := WhaleTransfer{
+		TokenTransfer: transfer,
+		AmountUsd:     amountUsd,
+		Direction:     WhaleDirectionTransfer,
+	}
+
+	var fromKind, toKind WalletKind
+	for _, wallet := range s.whaleConfig.KnownWallets {
+		if wallet.Address == transfer.From {
+			whale.FromLabel, fromKind = wallet.Label, wallet.Kind
+		}
+		if wallet.Address == transfer.To {
+			whale.ToLabel, toKind = wallet.Label, wallet.Kind
+		}
+	}
+
+	switch {
+	case fromKind == WalletKindLP:
+		whale.Direction = WhaleDirectionBuy
+	case toKind == WalletKindLP:
+		whale.Direction = WhaleDirectionSell
+	case toKind == WalletKindCEX:
+		whale.Direction = WhaleDirectionDeposit
+	case fromKind == WalletKindCEX:
+		whale.Direction = WhaleDirectionWithdrawal
+	}
+
+	return whale
+}
+
+// recordWhaleTransfer stores the transfer in the sora fragment table and reports whether it is new.
+// Transfers that were already recorded, for example before a restart, are skipped.
+func (s *SoraManager) recordWhaleTransfer(token WatchedToken, transfer WhaleTransfer) (bool, error) {
+	existing, err := s.FragmentStore.SearchByFilter(stores.FragmentFilter{
+		SessionID: &marketSessionID,
+		Metadata: []stores.MetadataCondition{
+			{Key: "type", Value: FragmentTypeWhaleTransfer, Operator: stores.MetadataOpEquals},
+			{Key: "signature", Value: transfer.Signature, Operator: stores.MetadataOpEquals},
+		},
+		Limit: 1,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to search whale transfers: %w", err)
+	}
+	if len(existing) > 0 {
+		return false, nil
+	}
+
+	timestamp := transfer.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	if err := s.Store(&db.Fragment{
+		ID:        id.New(),
+		ActorID:   s.AssistantID,
+		SessionID: marketSessionID,
+		Content:   describeWhaleTransfer(token, transfer),
+		Embedding: pgvector.NewVector(make([]float32, 1536)),
+		Metadata: db.Metadata{
+			"type":       FragmentTypeWhaleTransfer,
+			"mint":       token.Mint,
+			"signature":  transfer.Signature,
+			"from":       transfer.From,
+			"to":         transfer.To,
+			"from_label": transfer.FromLabel,
+			"to_label":   transfer.ToLabel,
+			"direction":  transfer.Direction,
+			"amount":     transfer.Amount,
+			"amount_usd": transfer.AmountUsd,
+		},
+		CreatedAt: timestamp,
+		UpdatedAt: timestamp,
+	}); err != nil {
+		return false, fmt.Errorf("failed to store whale transfer: %w", err)
+	}
+
+	s.Logger.WithFields(map[string]interface{}{
+		"signature":  transfer.Signature,
+		"direction":  transfer.Direction,
+		"amount_usd": transfer.AmountUsd,
+	}).Infof("Whale transfer recorded")
+
+	return true, nil
+}
+
+// whaleActivity summarizes the whale transfers within the context window, newest first
+func (s *SoraManager) whaleActivity(now time.Time) (string, error) {
+	since := now.Add(-s.whaleConfig.ContextWindow)
+	fragments, err := s.FragmentStore.SearchByFilter(stores.FragmentFilter{
+		SessionID: &marketSessionID,
+		Metadata: []stores.MetadataCondition{
+			{Key: "type", Value: FragmentTypeWhaleTransfer, Operator: stores.MetadataOpEquals},
+		},
+		StartTime: &since,
+		Limit:     whaleContextLimit,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search whale transfers: %w", err)
+	}
+	if len(fragments) == 0 {
+		return "", nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Whale activity in the last %v:", s.whaleConfig.ContextWindow))
+	for _, fragment := range fragments {
+		builder.WriteString(fmt.Sprintf("\n- %s (%s ago)", fragment.Content, now.Sub(fragment.CreatedAt).Round(time.Minute)))
+	}
+
+	return builder.String(), nil
+}
+
+// describeWhaleTransfer formats a whale transfer for prompts, unknown wallets are shortened
+func describeWhaleTransfer(token WatchedToken, transfer WhaleTransfer) string {
+	amount := fmt.Sprintf("%s %s (%.0f USD)", formatAmount(transfer.Amount), token.Name, transfer.AmountUsd)
+	from := walletName(transfer.From, transfer.FromLabel)
+	to := walletName(transfer.To, transfer.ToLabel)
+
+	switch transfer.Direction {
+	case WhaleDirectionBuy:
+		return fmt.Sprintf("%s bought %s from %s", to, amount, from)
+	case WhaleDirectionSell:
+		return fmt.Sprintf("%s sold %s into %s", from, amount, to)
+	case WhaleDirectionDeposit:
+		return fmt.Sprintf("%s deposited %s to %s", from, amount, to)
+	case WhaleDirectionWithdrawal:
+		return fmt.Sprintf("%s withdrew %s from %s", to, amount, from)
+	default:
+		return fmt.Sprintf("%s moved %s to %s", from, amount, to)
+	}
+}
+
+func walletName(address, label string) string {
+	if label != "" {
+		return label
+	}
+	return "wallet " + shortAddress(address)
+}
+
+// soraToken returns the watched token with the Sora mint, Sora is always watched on-chain
+func (s *SoraManager) soraToken() WatchedToken {
+	for _, token := range s.watchlist {
+		if token.Mint == SoraMintAddress {
+			return token
+		}
+	}
+	return DefaultWatchlist[0]
+}
@@ -0,0 +1,143 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/soralabs/hana/internal/dexscreener"
+	"github.com/soralabs/hana/internal/jupiter"
+	"github.com/soralabs/zen/options"
+)
+
+// DefaultMaxDeviation is the largest price difference between providers in percent
+// that is still published
+const DefaultMaxDeviation = 15.0
+
+// Chain asks its providers in priority order and cross checks the first answer
+// against the next provider that answers
+type Chain struct {
+	options.RequiredFields
+
+	providers    []MarketDataProvider
+	maxDeviation float64
+}
+
+// DeviationError is returned when two providers disagree on a token's price
+type DeviationError struct {
+	Mint string
+	// Prices are keyed by provider name
+	Prices map[string]float64
+	// Deviation is the difference between the prices in percent
+	Deviation float64
+}
+
+func (e *DeviationError) Error() string {
+	return fmt.Sprintf("prices for %s deviate by %.2f%% between providers: %v", e.Mint, e.Deviation, e.Prices)
+}
+
+// NewChain creates a fallback chain over the providers, the first provider has the highest priority
+func NewChain(providers []MarketDataProvider, opts ...options.Option[Chain]) (*Chain, error) {
+	c := &Chain{
+		providers:    providers,
+		maxDeviation: DefaultMaxDeviation,
+	}
+
+	if err := options.ApplyOptions(c, opts...); err != nil {
+		return nil, fmt.Errorf("failed to apply options: %w", err)
+	}
+
+	return c, nil
+}
+
+// ValidateRequiredFields checks that the chain has providers and a deviation limit
+func (c *Chain) ValidateRequiredFields() error {
+	if len(c.providers) == 0 {
+		return fmt.Errorf("at least one provider is required")
+	}
+	if c.maxDeviation <= 0 {
+		return fmt.Errorf("max deviation must be positive")
+	}
+	return nil
+}
+
+// WithMaxDeviation sets the largest price difference between providers in percent that is still published
+func WithMaxDeviation(percent float64) options.Option[Chain] {
+	return func(c *Chain) error {
+		c.maxDeviation = percent
+		return nil
+	}
+}
+
+func (c *Chain) Name() string {
+	return "chain"
+}
+
+// TokenMarket returns the market data of the highest priority provider that answers.
+// Its price is compared to the next provider that answers and refused with a DeviationError
+// if they disagree too much. When no other provider answers the data is returned unchecked.
+func (c *Chain) TokenMarket(ctx context.Context, mint string) (*TokenMarket, error) {
+	var primary *TokenMarket
+	var errs []error
+
+	for _, provider := range c.providers {
+		market, err := provider.TokenMarket(ctx, mint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+			continue
+		}
+
+		if primary == nil {
+			primary = market
+			continue
+		}
+
+		if deviation := priceDeviation(primary.PriceUsd, market.PriceUsd); deviation > c.maxDeviation {
+			return nil, &DeviationError{
+				Mint: mint,
+				Prices: map[string]float64{
+					primary.Provider: primary.PriceUsd,
+					market.Provider:  market.PriceUsd,
+				},
+				Deviation: deviation,
+			}
+		}
+
+		primary.CrossCheckedBy = append(primary.CrossCheckedBy, market.Provider)
+		return primary, nil
+	}
+
+	if primary == nil {
+		return nil, fmt.Errorf("no market data provider answered: %w", errors.Join(errs...))
+	}
+
+	return primary, nil
+}
+
+// priceDeviation returns the difference between two prices in percent of the lower one
+func priceDeviation(a, b float64) float64 {
+	lower := math.Min(a, b)
+	if lower <= 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(a-b) / lower * 100
+}
+
+// NewDefaultChain creates a chain that prefers DexScreener and cross checks it against Jupiter
+func NewDefaultChain(opts ...options.Option[Chain]) (*Chain, error) {
+	dexscreenerClient, err := dexscreener.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dexscreener client: %w", err)
+	}
+
+	jupiterClient, err := jupiter.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jupiter client: %w", err)
+	}
+
+	return NewChain([]MarketDataProvider{
+		NewDexScreenerProvider(dexscreenerClient),
+		NewJupiterProvider(jupiterClient),
+	}, opts...)
+}
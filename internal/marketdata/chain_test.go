@@ -0,0 +1,141 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math"
+	"slices"
+	"testing"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+// fakeProvider answers with its price, or with its error when set
+type fakeProvider struct {
+	name  string
+	price float64
+	err   error
+}
+
+func (p fakeProvider) Name() string {
+	return p.name
+}
+
+func (p fakeProvider) TokenMarket(ctx context.Context, mint string) (*TokenMarket, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &TokenMarket{Provider: p.name, Mint: mint, PriceUsd: p.price}, nil
+}
+
+func TestChainTokenMarket(t *testing.T) {
+	const mint = "89nnWMkWeF9LSJvAWcN2JFQfeWdDk6diKEckeToEU1hE"
+
+	tests := []struct {
+		name      string
+		providers []MarketDataProvider
+		// wantProvider is the provider whose market is returned, empty when the chain fails
+		wantProvider     string
+		wantCrossChecked []string
+		wantDeviation    *DeviationError
+	}{
+		{
+			name:             "agreeing quotes",
+			providers:        []MarketDataProvider{fakeProvider{name: "dexscreener", price: 1}, fakeProvider{name: "jupiter", price: 1.1}},
+			wantProvider:     "dexscreener",
+			wantCrossChecked: []string{"jupiter"},
+		},
+		{
+			name:             "quotes deviating by the threshold",
+			providers:        []MarketDataProvider{fakeProvider{name: "dexscreener", price: 1}, fakeProvider{name: "jupiter", price: 1.15}},
+			wantProvider:     "dexscreener",
+			wantCrossChecked: []string{"jupiter"},
+		},
+		{
+			name:      "quotes deviating past the threshold",
+			providers: []MarketDataProvider{fakeProvider{name: "dexscreener", price: 1}, fakeProvider{name: "jupiter", price: 1.2}},
+			wantDeviation: &DeviationError{
+				Mint:      mint,
+				Prices:    map[string]float64{"dexscreener": 1, "jupiter": 1.2},
+				Deviation: 20,
+			},
+		},
+		{
+			name:      "primary without a price",
+			providers: []MarketDataProvider{fakeProvider{name: "dexscreener"}, fakeProvider{name: "jupiter", price: 1}},
+			wantDeviation: &DeviationError{
+				Mint:   mint,
+				Prices: map[string]float64{"dexscreener": 0, "jupiter": 1},
+			},
+		},
+		{
+			name: "primary failing",
+			providers: []MarketDataProvider{
+				fakeProvider{name: "dexscreener", err: errUnavailable},
+				fakeProvider{name: "jupiter", price: 1},
+				fakeProvider{name: "birdeye", price: 1.05},
+			},
+			wantProvider:     "jupiter",
+			wantCrossChecked: []string{"birdeye"},
+		},
+		{
+			name:         "only one provider answering",
+			providers:    []MarketDataProvider{fakeProvider{name: "dexscreener", err: errUnavailable}, fakeProvider{name: "jupiter", price: 1}},
+			wantProvider: "jupiter",
+		},
+		{
+			name: "every provider failing",
+			providers: []MarketDataProvider{
+				fakeProvider{name: "dexscreener", err: errUnavailable},
+				fakeProvider{name: "jupiter", err: errUnavailable},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := NewChain(tt.providers)
+			if err != nil {
+				t.Fatalf("NewChain: %v", err)
+			}
+
+			market, err := chain.TokenMarket(context.Background(), mint)
+
+			var deviationErr *DeviationError
+			switch {
+			case tt.wantDeviation != nil:
+				if !errors.As(err, &deviationErr) {
+					t.Fatalf("got market %+v and error %v, want a deviation error", market, err)
+				}
+				if deviationErr.Mint != tt.wantDeviation.Mint || !maps.Equal(deviationErr.Prices, tt.wantDeviation.Prices) {
+					t.Errorf("got deviation error %+v, want %+v", deviationErr, tt.wantDeviation)
+				}
+				// a deviation of zero stands for one that can't be computed
+				if tt.wantDeviation.Deviation > 0 && !approxEqual(deviationErr.Deviation, tt.wantDeviation.Deviation) {
+					t.Errorf("got a deviation of %.2f%%, want %.2f%%", deviationErr.Deviation, tt.wantDeviation.Deviation)
+				}
+			case tt.wantProvider == "":
+				if err == nil {
+					t.Fatalf("got market %+v, want an error", market)
+				}
+				if !errors.Is(err, errUnavailable) {
+					t.Errorf("got error %v, want it to wrap the providers' errors", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("TokenMarket: %v", err)
+				}
+				if market.Provider != tt.wantProvider || !slices.Equal(market.CrossCheckedBy, tt.wantCrossChecked) {
+					t.Errorf("got market of %s cross checked by %v, want %s cross checked by %v",
+						market.Provider, market.CrossCheckedBy, tt.wantProvider, tt.wantCrossChecked)
+				}
+			}
+		})
+	}
+}
+
+// approxEqual compares percentages computed from float prices
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
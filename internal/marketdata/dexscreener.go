@@ -0,0 +1,72 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/soralabs/hana/internal/dexscreener"
+)
+
+// DexScreenerProvider reads prices and trading activity from a token's highest liquidity pool
+type DexScreenerProvider struct {
+	client *dexscreener.Client
+}
+
+// NewDexScreenerProvider creates a provider backed by the given DexScreener client
+func NewDexScreenerProvider(client *dexscreener.Client) *DexScreenerProvider {
+	return &DexScreenerProvider{client: client}
+}
+
+func (p *DexScreenerProvider) Name() string {
+	return "dexscreener"
+}
+
+func (p *DexScreenerProvider) TokenMarket(ctx context.Context, mint string) (*TokenMarket, error) {
+	pairs, err := p.client.TokenPairs(ctx, "solana", mint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token pairs: %w", err)
+	}
+
+	pair, err := dexscreener.HighestLiquidityPair(pairs, "solana", mint)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := strconv.ParseFloat(pair.PriceUsd, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse price %q: %w", pair.PriceUsd, err)
+	}
+
+	return &TokenMarket{
+		Provider:    p.Name(),
+		Mint:        mint,
+		Symbol:      pair.BaseToken.Symbol,
+		PairAddress: pair.PairAddress,
+		PriceUsd:    price,
+		PriceNative: pair.PriceNative,
+		Activity: &TradingActivity{
+			Volume: Windows{
+				M5:  pair.Volume.M5,
+				H1:  pair.Volume.H1,
+				H6:  pair.Volume.H6,
+				H24: pair.Volume.H24,
+			},
+			PriceChange: Windows{
+				M5:  pair.PriceChange.M5,
+				H1:  pair.PriceChange.H1,
+				H6:  pair.PriceChange.H6,
+				H24: pair.PriceChange.H24,
+			},
+			Txns: TxnWindows{
+				M5:  TxnCount{Buys: pair.Txns.M5.Buys, Sells: pair.Txns.M5.Sells},
+				H1:  TxnCount{Buys: pair.Txns.H1.Buys, Sells: pair.Txns.H1.Sells},
+				H6:  TxnCount{Buys: pair.Txns.H6.Buys, Sells: pair.Txns.H6.Sells},
+				H24: TxnCount{Buys: pair.Txns.H24.Buys, Sells: pair.Txns.H24.Sells},
+			},
+			LiquidityUsd: pair.Liquidity.Usd,
+			MarketCap:    pair.MarketCap,
+			Fdv:          pair.Fdv,
+		},
+	}, nil
+}
@@ -0,0 +1,67 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/soralabs/hana/internal/jupiter"
+	"github.com/soralabs/zen/cache"
+)
+
+// JupiterProvider reads prices from the Jupiter price API, it reports no trading activity
+type JupiterProvider struct {
+	client *jupiter.Client
+	cache  *cache.Cache
+}
+
+// NewJupiterProvider creates a provider backed by the given Jupiter client
+func NewJupiterProvider(client *jupiter.Client) *JupiterProvider {
+	return &JupiterProvider{
+		client: client,
+		cache: cache.New(cache.Config{
+			MaxSize:       1000,
+			TTL:           30 * time.Second,
+			CleanupPeriod: 1 * time.Minute,
+		}),
+	}
+}
+
+func (p *JupiterProvider) Name() string {
+	return "jupiter"
+}
+
+func (p *JupiterProvider) TokenMarket(ctx context.Context, mint string) (*TokenMarket, error) {
+	cacheKey := cache.CacheKey(mint)
+	if cached, exists := p.cache.Get(cacheKey); exists {
+		market := *cached.(*TokenMarket)
+		return &market, nil
+	}
+
+	prices, err := p.client.Prices(ctx, mint)
+	if err != nil {
+		return nil, err
+	}
+
+	price, exists := prices[mint]
+	if !exists {
+		return nil, fmt.Errorf("no jupiter price for %s", mint)
+	}
+
+	priceUsd, err := strconv.ParseFloat(price.Price, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse price %q: %w", price.Price, err)
+	}
+
+	market := &TokenMarket{
+		Provider: p.Name(),
+		Mint:     mint,
+		PriceUsd: priceUsd,
+	}
+	p.cache.Set(cacheKey, market)
+
+	// callers get a copy so the cross check can't change the cached value
+	copied := *market
+	return &copied, nil
+}
@@ -0,0 +1,57 @@
+package marketdata
+
+import "context"
+
+// MarketDataProvider is a source of token market data
+type MarketDataProvider interface {
+	// Name identifies the provider in logs and errors
+	Name() string
+	// TokenMarket returns the current market data of a Solana token
+	TokenMarket(ctx context.Context, mint string) (*TokenMarket, error)
+}
+
+// TokenMarket is a token's market data as reported by a provider
+type TokenMarket struct {
+	// Provider is the name of the provider the data came from
+	Provider    string
+	Mint        string
+	Symbol      string
+	PairAddress string
+	PriceUsd    float64
+	PriceNative string
+	// Activity is nil when the provider only reports prices
+	Activity *TradingActivity
+	// CrossCheckedBy lists the providers whose price agreed, empty when no other source answered
+	CrossCheckedBy []string
+}
+
+// TradingActivity is the trading data of a token's main pool
+type TradingActivity struct {
+	Volume       Windows
+	PriceChange  Windows
+	Txns         TxnWindows
+	LiquidityUsd float64
+	MarketCap    float64
+	Fdv          float64
+}
+
+// Windows holds a value over the last 5 minutes, hour, 6 hours and day
+type Windows struct {
+	M5  float64
+	H1  float64
+	H6  float64
+	H24 float64
+}
+
+// TxnWindows holds the transaction counts over the last 5 minutes, hour, 6 hours and day
+type TxnWindows struct {
+	M5  TxnCount
+	H1  TxnCount
+	H6  TxnCount
+	H24 TxnCount
+}
+
+type TxnCount struct {
+	Buys  int
+	Sells int
+}
@@ -0,0 +1,109 @@
+// Package testutil sets up the logger, the sqlite database and the LLM client that package tests share
+package testutil
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/llm"
+	"github.com/soralabs/zen/logger"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// NewLogger creates a logger that only logs errors
+func NewLogger(t testing.TB) *logger.Logger {
+	t.Helper()
+
+	log, err := logger.New(&logger.Config{
+		Level:      "error",
+		TimeFormat: "2006-01-02 15:04:05",
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	return log
+}
+
+// NewDatabase opens a sqlite database in a temporary directory and migrates the models
+func NewDatabase(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	database, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if len(models) > 0 {
+		if err := database.AutoMigrate(models...); err != nil {
+			t.Fatalf("failed to migrate database: %v", err)
+		}
+	}
+	return database
+}
+
+// CreateFragmentTables creates sqlite versions of zen's actors and sessions tables and of the fragment tables.
+// zen's own migrations need Postgres.
+func CreateFragmentTables(t testing.TB, database *gorm.DB, tables ...db.FragmentTable) {
+	t.Helper()
+
+	statements := []string{
+		`CREATE TABLE actors (id TEXT PRIMARY KEY, name TEXT NOT NULL, assistant NUMERIC NOT NULL DEFAULT 0,
+			created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE sessions (id TEXT PRIMARY KEY, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+	}
+	for _, table := range tables {
+		statements = append(statements, `CREATE TABLE `+string(table)+` (id TEXT PRIMARY KEY, actor_id TEXT NOT NULL,
+			session_id TEXT NOT NULL, content TEXT NOT NULL, metadata TEXT NOT NULL DEFAULT '{}', embedding TEXT,
+			created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`)
+	}
+
+	for _, statement := range statements {
+		if err := database.Exec(statement).Error; err != nil {
+			t.Fatalf("failed to create table: %v", err)
+		}
+	}
+}
+
+// NewLLMClient creates an OpenAI client whose requests go to the server at serverURL.
+// The client has no base URL option, so every request of http.DefaultTransport is redirected until the test ends.
+func NewLLMClient(t testing.TB, log *logger.Logger, serverURL string) *llm.LLMClient {
+	t.Helper()
+
+	target, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatalf("invalid server URL: %v", err)
+	}
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = redirectTransport{target: target, transport: defaultTransport}
+	t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+
+	client, err := llm.NewLLMClient(llm.Config{
+		DefaultProvider: llm.ProviderConfig{Type: llm.ProviderOpenAI, APIKey: "test"},
+		Logger:          log,
+		Context:         context.Background(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create LLM client: %v", err)
+	}
+	return client
+}
+
+// redirectTransport sends every request to the target
+type redirectTransport struct {
+	target    *url.URL
+	transport http.RoundTripper
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return t.transport.RoundTrip(req)
+}
@@ -0,0 +1,84 @@
+package tipping
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/soralabs/zen/id"
+)
+
+// reserve records a pending tip if the candidate and the daily budget have room for it.
+// Returns nil when the tip doesn't fit.
+func (t *Tipper) reserve(candidate Candidate, quality float64) (*TipRecord, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	dayStart := time.Now().UTC().Truncate(24 * time.Hour)
+
+	var spent float64
+	if err := t.database.Model(&TipRecord{}).
+		Where("created_at >= ? AND dry_run = ? AND status <> ?", dayStart, t.config.DryRun, TipStatusCanceled).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&spent).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum today's tips: %w", err)
+	}
+	if spent+t.config.TipAmount > t.config.DailyBudget {
+		t.logger.Infof("Daily tip budget spent (%.2f of %.2f), not tipping @%s", spent, t.config.DailyBudget, candidate.Username)
+		return nil, nil
+	}
+
+	var actorTips int64
+	if err := t.database.Model(&TipRecord{}).
+		Where("actor_id = ? AND created_at >= ? AND dry_run = ? AND status <> ?", candidate.ActorID, dayStart, t.config.DryRun, TipStatusCanceled).
+		Count(&actorTips).Error; err != nil {
+		return nil, fmt.Errorf("failed to count today's tips of @%s: %w", candidate.Username, err)
+	}
+	if actorTips >= int64(t.config.MaxTipsPerActor) {
+		return nil, nil
+	}
+
+	var tweetTips int64
+	if err := t.database.Model(&TipRecord{}).Where("tweet_id = ?", candidate.TweetID).Count(&tweetTips).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up tips of tweet %s: %w", candidate.TweetID, err)
+	}
+	if tweetTips > 0 {
+		return nil, nil
+	}
+
+	record := &TipRecord{
+		ID:       id.New(),
+		ActorID:  candidate.ActorID,
+		Username: candidate.Username,
+		TweetID:  candidate.TweetID,
+		Wallet:   candidate.Wallet,
+		Mint:     t.mint,
+		Amount:   t.config.TipAmount,
+		Quality:  quality,
+		Status:   TipStatusPending,
+		DryRun:   t.config.DryRun,
+	}
+	if err := t.database.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to record pending tip: %w", err)
+	}
+
+	return record, nil
+}
+
+// finish moves a pending tip to its final status
+func (t *Tipper) finish(record *TipRecord, status TipStatus, signature string, tipErr error) error {
+	record.Status = status
+	record.Signature = signature
+	if tipErr != nil {
+		record.Error = tipErr.Error()
+	}
+
+	if err := t.database.Model(record).Updates(map[string]interface{}{
+		"status":    record.Status,
+		"signature": record.Signature,
+		"error":     record.Error,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update tip: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package tipping
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/soralabs/zen/id"
+)
+
+func TestReserve(t *testing.T) {
+	actorID := id.New()
+	yesterday := time.Now().UTC().Truncate(24 * time.Hour).Add(-time.Hour)
+
+	// tip returns an earlier ledger entry
+	tip := func(actor id.ID, tweetID string, status TipStatus, dryRun bool, createdAt time.Time) TipRecord {
+		return TipRecord{
+			ID:        id.New(),
+			ActorID:   actor,
+			TweetID:   tweetID,
+			Wallet:    solana.NewWallet().PublicKey().String(),
+			Mint:      solana.NewWallet().PublicKey().String(),
+			Amount:    testConfig.TipAmount,
+			Status:    status,
+			DryRun:    dryRun,
+			CreatedAt: createdAt,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		ledger    []TipRecord
+		candidate Candidate
+		want      bool
+	}{
+		{
+			name:      "empty ledger",
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+			want:      true,
+		},
+		{
+			name: "budget spent",
+			ledger: []TipRecord{
+				tip(id.New(), "2", TipStatusSimulated, true, time.Now()),
+				tip(id.New(), "3", TipStatusPending, true, time.Now()),
+			},
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+		},
+		{
+			name: "failed tips count against the budget",
+			ledger: []TipRecord{
+				tip(id.New(), "2", TipStatusSimulated, true, time.Now()),
+				tip(id.New(), "3", TipStatusFailed, true, time.Now()),
+			},
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+		},
+		{
+			name: "canceled tips don't count against the budget",
+			ledger: []TipRecord{
+				tip(id.New(), "2", TipStatusSimulated, true, time.Now()),
+				tip(id.New(), "3", TipStatusCanceled, true, time.Now()),
+			},
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+			want:      true,
+		},
+		{
+			name: "yesterday's tips don't count against the budget",
+			ledger: []TipRecord{
+				tip(id.New(), "2", TipStatusSimulated, true, yesterday),
+				tip(id.New(), "3", TipStatusSimulated, true, yesterday),
+			},
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+			want:      true,
+		},
+		{
+			name: "real tips don't count against the dry run budget",
+			ledger: []TipRecord{
+				tip(id.New(), "2", TipStatusSent, false, time.Now()),
+				tip(id.New(), "3", TipStatusSent, false, time.Now()),
+			},
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+			want:      true,
+		},
+		{
+			name:      "actor already tipped today",
+			ledger:    []TipRecord{tip(actorID, "2", TipStatusSimulated, true, time.Now())},
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+		},
+		{
+			name:      "actor tipped yesterday",
+			ledger:    []TipRecord{tip(actorID, "2", TipStatusSimulated, true, yesterday)},
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+			want:      true,
+		},
+		{
+			name:      "actor's canceled tip",
+			ledger:    []TipRecord{tip(actorID, "2", TipStatusCanceled, true, time.Now())},
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+			want:      true,
+		},
+		{
+			name:      "tweet already tipped",
+			ledger:    []TipRecord{tip(id.New(), "1", TipStatusSimulated, true, yesterday)},
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+		},
+		{
+			name:      "tweet's tip was canceled",
+			ledger:    []TipRecord{tip(id.New(), "1", TipStatusCanceled, true, time.Now())},
+			candidate: Candidate{ActorID: actorID, TweetID: "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tipper, database := newTestTipper(t, newFakeChain(t), testConfig)
+			for _, record := range tt.ledger {
+				if err := database.Create(&record).Error; err != nil {
+					t.Fatalf("failed to record earlier tip: %v", err)
+				}
+			}
+
+			tt.candidate.Wallet = solana.NewWallet().PublicKey().String()
+			record, err := tipper.reserve(tt.candidate, 0.9)
+			if err != nil {
+				t.Fatalf("reserve: %v", err)
+			}
+			if got := record != nil; got != tt.want {
+				t.Fatalf("got reserved %v, want %v", got, tt.want)
+			}
+			if record == nil {
+				return
+			}
+
+			var stored TipRecord
+			if err := database.First(&stored, "id = ?", record.ID).Error; err != nil {
+				t.Fatalf("failed to read the reserved tip: %v", err)
+			}
+			if stored.Status != TipStatusPending || stored.Amount != testConfig.TipAmount || !stored.DryRun {
+				t.Errorf("got ledger entry %+v, want a pending dry run tip of %v", stored, testConfig.TipAmount)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package tipping
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/soralabs/zen/llm"
+	"github.com/soralabs/zen/logger"
+	"github.com/soralabs/zen/options"
+	"gorm.io/gorm"
+)
+
+// ValidateRequiredFields checks the dependencies and that the signer is the allowlisted sender
+func (t *Tipper) ValidateRequiredFields() error {
+	if t.logger == nil {
+		return fmt.Errorf("logger is required")
+	}
+	if t.database == nil {
+		return fmt.Errorf("database is required")
+	}
+	if t.llmClient == nil {
+		return fmt.Errorf("LLM client is required")
+	}
+	if t.solanaRPC == nil {
+		return fmt.Errorf("solana RPC client is required")
+	}
+	if len(t.signer) == 0 {
+		return fmt.Errorf("signer is required")
+	}
+	if t.allowedSender == "" {
+		return fmt.Errorf("allowed sender is required")
+	}
+	if t.signer.PublicKey().String() != t.allowedSender {
+		return fmt.Errorf("signer %s is not the allowed sender %s", t.signer.PublicKey(), t.allowedSender)
+	}
+	if _, err := solana.PublicKeyFromBase58(t.mint); err != nil {
+		return fmt.Errorf("invalid mint %s: %w", t.mint, err)
+	}
+	if t.config.TipAmount <= 0 || t.config.DailyBudget < t.config.TipAmount {
+		return fmt.Errorf("tip amount must be positive and fit in the daily budget")
+	}
+	if t.config.MaxTipsPerActor <= 0 {
+		return fmt.Errorf("max tips per actor must be positive")
+	}
+	if t.config.MinQuality <= 0 || t.config.MinQuality > 1 {
+		return fmt.Errorf("min quality must be between 0 and 1")
+	}
+	return nil
+}
+
+// WithLogger sets the logger
+func WithLogger(logger *logger.Logger) options.Option[Tipper] {
+	return func(t *Tipper) error {
+		t.logger = logger
+		return nil
+	}
+}
+
+// WithDatabase sets the database the ledger table lives in
+func WithDatabase(database *gorm.DB) options.Option[Tipper] {
+	return func(t *Tipper) error {
+		t.database = database
+		return nil
+	}
+}
+
+// WithLLM sets the LLM client used to score tweet quality
+func WithLLM(llmClient *llm.LLMClient) options.Option[Tipper] {
+	return func(t *Tipper) error {
+		t.llmClient = llmClient
+		return nil
+	}
+}
+
+// WithSolanaRPC sets the RPC client transactions are sent or simulated through
+func WithSolanaRPC(solanaRPC *rpc.Client) options.Option[Tipper] {
+	return func(t *Tipper) error {
+		t.solanaRPC = solanaRPC
+		return nil
+	}
+}
+
+// WithSigner sets the wallet tips are paid from, it must match the allowed sender
+func WithSigner(signer solana.PrivateKey) options.Option[Tipper] {
+	return func(t *Tipper) error {
+		t.signer = signer
+		return nil
+	}
+}
+
+// WithAllowedSender sets the only wallet address tips can be paid from
+func WithAllowedSender(address string) options.Option[Tipper] {
+	return func(t *Tipper) error {
+		t.allowedSender = address
+		return nil
+	}
+}
+
+// WithMint sets the token that is tipped
+func WithMint(mint string) options.Option[Tipper] {
+	return func(t *Tipper) error {
+		t.mint = mint
+		return nil
+	}
+}
+
+// WithConfig sets the tip amount, budget and safety limits
+func WithConfig(config Config) options.Option[Tipper] {
+	return func(t *Tipper) error {
+		t.config = config
+		return nil
+	}
+}
@@ -0,0 +1,156 @@
+package tipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/soralabs/zen/llm"
+	"github.com/soralabs/zen/options"
+)
+
+const (
+	// TipStatusPending reserves the budget while the transaction is sent
+	TipStatusPending   TipStatus = "pending"
+	TipStatusSent      TipStatus = "sent"
+	TipStatusSimulated TipStatus = "simulated"
+	TipStatusFailed    TipStatus = "failed"
+	// TipStatusCanceled tips were stopped by the kill switch before signing and don't count against the budget
+	TipStatusCanceled TipStatus = "canceled"
+)
+
+// ErrStopped is returned while the kill switch is engaged
+var ErrStopped = errors.New("tipping is stopped by the kill switch")
+
+// DefaultConfig only simulates tips
+var DefaultConfig = Config{
+	TipAmount:       100,
+	DailyBudget:     1000,
+	MaxTipsPerActor: 1,
+	MinQuality:      0.8,
+	DryRun:          true,
+}
+
+// New creates a tipper and the ledger table if it doesn't exist yet
+func New(opts ...options.Option[Tipper]) (*Tipper, error) {
+	t := &Tipper{
+		config: DefaultConfig,
+	}
+
+	if err := options.ApplyOptions(t, opts...); err != nil {
+		return nil, fmt.Errorf("failed to apply options: %w", err)
+	}
+
+	if err := t.database.AutoMigrate(&TipRecord{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate tip ledger: %w", err)
+	}
+
+	return t, nil
+}
+
+// Halt engages the in-process kill switch, no tip is sent again until restart
+func (t *Tipper) Halt(reason string) {
+	if t.halted.CompareAndSwap(false, true) {
+		t.logger.Errorf("Tipping halted: %s", reason)
+	}
+}
+
+// stopped reports whether the kill switch is engaged, either by Halt or through the kill switch file
+func (t *Tipper) stopped() bool {
+	if t.halted.Load() {
+		return true
+	}
+	if t.config.KillSwitchFile == "" {
+		return false
+	}
+	// anything but a missing file stops tipping, so an unreadable path fails closed
+	_, err := os.Stat(t.config.KillSwitchFile)
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+// Consider tips the candidate when the tweet scores at least the minimum quality and the budget allows it.
+// Returns the ledger entry of the tip, nil when the tweet isn't tipped.
+func (t *Tipper) Consider(ctx context.Context, candidate Candidate) (*TipRecord, error) {
+	if t.stopped() {
+		return nil, ErrStopped
+	}
+	if _, err := solana.PublicKeyFromBase58(candidate.Wallet); err != nil {
+		return nil, fmt.Errorf("invalid wallet %s: %w", candidate.Wallet, err)
+	}
+
+	quality, reason, err := t.scoreQuality(candidate)
+	if err != nil {
+		return nil, err
+	}
+	if quality < t.config.MinQuality {
+		return nil, nil
+	}
+
+	record, err := t.reserve(candidate, quality)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	t.logger.WithFields(map[string]interface{}{
+		"tweet_id": candidate.TweetID,
+		"user":     candidate.Username,
+		"quality":  quality,
+		"reason":   reason,
+		"amount":   record.Amount,
+		"dry_run":  record.DryRun,
+	}).Infof("Tipping tweet")
+
+	// the kill switch is checked again right before signing since scoring takes a while
+	if t.stopped() {
+		if err := t.finish(record, TipStatusCanceled, "", ErrStopped); err != nil {
+			t.logger.Warnf("failed to cancel tip %s: %v", record.ID, err)
+		}
+		return nil, ErrStopped
+	}
+
+	signature, err := t.transfer(ctx, candidate.Wallet, record.Amount)
+	if err != nil {
+		if finishErr := t.finish(record, TipStatusFailed, signature, err); finishErr != nil {
+			t.Halt(fmt.Sprintf("failed to record failed tip %s: %v", record.ID, finishErr))
+		}
+		return record, fmt.Errorf("failed to send tip: %w", err)
+	}
+
+	status := TipStatusSent
+	if record.DryRun {
+		status = TipStatusSimulated
+	}
+	if err := t.finish(record, status, signature, nil); err != nil {
+		// the pending entry keeps the budget reserved, but the ledger can't be trusted anymore
+		t.Halt(fmt.Sprintf("failed to record tip %s with signature %s: %v", record.ID, signature, err))
+		return record, err
+	}
+
+	return record, nil
+}
+
+// scoreQuality asks the LLM how valuable the tweet is to the community, between 0 and 1
+func (t *Tipper) scoreQuality(candidate Candidate) (float64, string, error) {
+	var response qualityResponse
+	err := t.llmClient.GenerateStructuredOutput(llm.StructuredOutputRequest{
+		Messages: []llm.Message{
+			llm.NewSystemMessage(`You score tweets sent to an AI agent for how much they add to its token community.
+High scores go to thoughtful questions, useful feedback, helpful answers to others, creative content and genuine support.
+Low scores go to spam, begging for tips or airdrops, low effort replies, shilling and hostility.
+Asking for a tip or airdrop always scores 0.
+Use the insights about the conversation and the author as context.`),
+			llm.NewUserMessage(fmt.Sprintf("Insights:\n%s\n\nTweet by @%s:\n%s", candidate.Insights, candidate.Username, candidate.Text)),
+		},
+		ModelType:    llm.ModelTypeDefault,
+		Temperature:  0.0,
+		SchemaName:   "tweet_quality",
+		StrictSchema: true,
+	}, &response)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to score tweet quality: %w", err)
+	}
+
+	return response.Score, response.Reason, nil
+}
@@ -0,0 +1,249 @@
+package tipping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/soralabs/hana/internal/testutil"
+	"github.com/soralabs/zen/id"
+	"gorm.io/gorm"
+)
+
+// testConfig tips 100 tokens from a budget of 200, once per actor, in dry run
+var testConfig = Config{
+	TipAmount:       100,
+	DailyBudget:     200,
+	MaxTipsPerActor: 1,
+	MinQuality:      0.5,
+	DryRun:          true,
+}
+
+// fakeChain answers the Solana RPC calls a transfer makes and the quality prompt of the LLM
+type fakeChain struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// onScore runs while a tweet is scored, before the score is returned
+	onScore func()
+	// simulations counts the simulated transactions
+	simulations int
+}
+
+func newFakeChain(t *testing.T) *fakeChain {
+	c := &fakeChain{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			c.score(w)
+			return
+		}
+		c.rpc(w, r)
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *fakeChain) score(w http.ResponseWriter) {
+	c.mu.Lock()
+	onScore := c.onScore
+	c.mu.Unlock()
+	if onScore != nil {
+		onScore()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"choices": []map[string]interface{}{{
+			"index": 0,
+			"message": map[string]string{
+				"role":    "assistant",
+				"content": `{"score":0.9,"reason":"a thoughtful question"}`,
+			},
+		}},
+	})
+}
+
+func (c *fakeChain) rpc(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	context := map[string]int{"slot": 1}
+	var result interface{}
+	switch request.Method {
+	case "getTokenSupply":
+		result = map[string]interface{}{
+			"context": context,
+			"value":   map[string]interface{}{"amount": "1000000000", "decimals": 6, "uiAmountString": "1000"},
+		}
+	case "getAccountInfo":
+		result = map[string]interface{}{"context": context, "value": nil}
+	case "getLatestBlockhash":
+		result = map[string]interface{}{
+			"context": context,
+			"value":   map[string]interface{}{"blockhash": solana.NewWallet().PublicKey().String(), "lastValidBlockHeight": 100},
+		}
+	case "simulateTransaction":
+		c.mu.Lock()
+		c.simulations++
+		c.mu.Unlock()
+		result = map[string]interface{}{
+			"context": context,
+			"value":   map[string]interface{}{"err": nil, "logs": []string{}},
+		}
+	default:
+		http.Error(w, "unexpected method "+request.Method, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "result": result})
+}
+
+func (c *fakeChain) simulated() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.simulations
+}
+
+// newTestTipper creates a tipper on a temporary sqlite ledger that sends its transactions to the fake chain
+func newTestTipper(t *testing.T, chain *fakeChain, config Config) (*Tipper, *gorm.DB) {
+	t.Helper()
+
+	log := testutil.NewLogger(t)
+	database := testutil.NewDatabase(t)
+
+	signer := solana.NewWallet().PrivateKey
+	tipper, err := New(
+		WithLogger(log),
+		WithDatabase(database),
+		WithLLM(testutil.NewLLMClient(t, log, chain.URL)),
+		WithSolanaRPC(rpc.New(chain.URL)),
+		WithSigner(signer),
+		WithAllowedSender(signer.PublicKey().String()),
+		WithMint(solana.NewWallet().PublicKey().String()),
+		WithConfig(config),
+	)
+	if err != nil {
+		t.Fatalf("failed to create tipper: %v", err)
+	}
+
+	return tipper, database
+}
+
+// newCandidate returns a candidate with its own actor, tweet and wallet
+func newCandidate() Candidate {
+	return Candidate{
+		ActorID:  id.New(),
+		Username: "alice",
+		TweetID:  id.New().String(),
+		Text:     "how does the agent decide what to reply to?",
+		Wallet:   solana.NewWallet().PublicKey().String(),
+	}
+}
+
+func TestConsiderSimulatesTip(t *testing.T) {
+	chain := newFakeChain(t)
+	tipper, _ := newTestTipper(t, chain, testConfig)
+
+	record, err := tipper.Consider(context.Background(), newCandidate())
+	if err != nil {
+		t.Fatalf("Consider: %v", err)
+	}
+	if record == nil || record.Status != TipStatusSimulated || record.Signature == "" {
+		t.Fatalf("got tip %+v, want a simulated tip with a signature", record)
+	}
+	if simulations := chain.simulated(); simulations != 1 {
+		t.Errorf("got %d simulated transactions, want 1", simulations)
+	}
+}
+
+func TestKillSwitchCanceledTipDoesNotCountAgainstBudget(t *testing.T) {
+	chain := newFakeChain(t)
+	config := testConfig
+	config.DailyBudget = config.TipAmount
+	config.KillSwitchFile = filepath.Join(t.TempDir(), "stop")
+	tipper, database := newTestTipper(t, chain, config)
+
+	// the kill switch is engaged while the tweet is scored, after the first check
+	chain.onScore = func() {
+		if err := os.WriteFile(config.KillSwitchFile, nil, 0o644); err != nil {
+			t.Errorf("failed to engage the kill switch: %v", err)
+		}
+	}
+	if _, err := tipper.Consider(context.Background(), newCandidate()); !errors.Is(err, ErrStopped) {
+		t.Fatalf("got error %v, want %v", err, ErrStopped)
+	}
+
+	var canceled TipRecord
+	if err := database.First(&canceled).Error; err != nil {
+		t.Fatalf("failed to read the canceled tip: %v", err)
+	}
+	if canceled.Status != TipStatusCanceled {
+		t.Errorf("got status %s, want %s", canceled.Status, TipStatusCanceled)
+	}
+	if simulations := chain.simulated(); simulations != 0 {
+		t.Errorf("got %d simulated transactions after the kill switch, want 0", simulations)
+	}
+
+	chain.onScore = nil
+	if err := os.Remove(config.KillSwitchFile); err != nil {
+		t.Fatal(err)
+	}
+	record, err := tipper.Consider(context.Background(), newCandidate())
+	if err != nil {
+		t.Fatalf("Consider after the kill switch: %v", err)
+	}
+	if record == nil {
+		t.Fatal("the canceled tip used up the budget")
+	}
+}
+
+func TestLedgerWriteFailureHaltsTipping(t *testing.T) {
+	chain := newFakeChain(t)
+	tipper, database := newTestTipper(t, chain, testConfig)
+
+	// pending tips can be recorded but not finished
+	if err := database.Callback().Update().Before("gorm:update").Register("fail_ledger_updates", func(tx *gorm.DB) {
+		tx.AddError(errors.New("disk full"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := tipper.Consider(context.Background(), newCandidate())
+	if err == nil {
+		t.Fatal("got no error for a tip that couldn't be recorded")
+	}
+	if record == nil || chain.simulated() != 1 {
+		t.Fatalf("got tip %+v after %d simulations, want the sent tip", record, chain.simulated())
+	}
+	if !tipper.stopped() {
+		t.Fatal("tipping wasn't halted")
+	}
+
+	if _, err := tipper.Consider(context.Background(), newCandidate()); !errors.Is(err, ErrStopped) {
+		t.Errorf("got error %v after the halt, want %v", err, ErrStopped)
+	}
+
+	var pending int64
+	if err := database.Model(&TipRecord{}).Where("status = ?", TipStatusPending).Count(&pending).Error; err != nil {
+		t.Fatal(err)
+	}
+	if pending != 1 {
+		t.Errorf("got %d pending tips, want the unrecorded tip to stay pending", pending)
+	}
+}
@@ -0,0 +1,110 @@
+package tipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// transfer sends amount tokens from the signer to the wallet, or only simulates it in dry run.
+// Returns the transaction signature.
+func (t *Tipper) transfer(ctx context.Context, wallet string, amount float64) (string, error) {
+	tx, err := t.buildTransfer(ctx, wallet, amount)
+	if err != nil {
+		return "", err
+	}
+	signature := tx.Signatures[0].String()
+
+	if t.config.DryRun {
+		result, err := t.solanaRPC.SimulateTransaction(ctx, tx)
+		if err != nil {
+			return signature, fmt.Errorf("failed to simulate transaction: %w", err)
+		}
+		if result.Value.Err != nil {
+			return signature, fmt.Errorf("simulated transaction failed: %v", result.Value.Err)
+		}
+		return signature, nil
+	}
+
+	sent, err := t.solanaRPC.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{
+		PreflightCommitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return signature, fmt.Errorf("failed to send transaction: %w", err)
+	}
+
+	return sent.String(), nil
+}
+
+// buildTransfer builds and signs a checked token transfer to the wallet's associated token account,
+// creating the account if the wallet never held the token
+func (t *Tipper) buildTransfer(ctx context.Context, wallet string, amount float64) (*solana.Transaction, error) {
+	mint := solana.MustPublicKeyFromBase58(t.mint)
+	owner := t.signer.PublicKey()
+	recipient, err := solana.PublicKeyFromBase58(wallet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wallet %s: %w", wallet, err)
+	}
+
+	supply, err := t.solanaRPC.GetTokenSupply(ctx, mint, rpc.CommitmentConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token decimals: %w", err)
+	}
+	decimals := supply.Value.Decimals
+
+	source, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sender token account: %w", err)
+	}
+	destination, _, err := solana.FindAssociatedTokenAddress(recipient, mint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find recipient token account: %w", err)
+	}
+
+	var instructions []solana.Instruction
+
+	_, err = t.solanaRPC.GetAccountInfo(ctx, destination)
+	if errors.Is(err, rpc.ErrNotFound) {
+		instructions = append(instructions, associatedtokenaccount.NewCreateInstruction(owner, recipient, mint).Build())
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get recipient token account: %w", err)
+	}
+
+	rawAmount := uint64(math.Round(amount * math.Pow10(int(decimals))))
+	instructions = append(instructions, token.NewTransferCheckedInstruction(
+		rawAmount,
+		decimals,
+		source,
+		mint,
+		destination,
+		owner,
+		nil,
+	).Build())
+
+	blockhash, err := t.solanaRPC.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest blockhash: %w", err)
+	}
+
+	tx, err := solana.NewTransaction(instructions, blockhash.Value.Blockhash, solana.TransactionPayer(owner))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transaction: %w", err)
+	}
+
+	if _, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(owner) {
+			return &t.signer
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	return tx, nil
+}
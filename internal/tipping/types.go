@@ -0,0 +1,99 @@
+package tipping
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/llm"
+	"github.com/soralabs/zen/logger"
+	"github.com/soralabs/zen/options"
+	"gorm.io/gorm"
+)
+
+// Tipper sends small token tips to the authors of high quality tweets.
+// Every tip is recorded in the ledger table before it is sent, so the daily budget
+// holds even when the process dies mid-way.
+type Tipper struct {
+	options.RequiredFields
+
+	logger    *logger.Logger
+	database  *gorm.DB
+	llmClient *llm.LLMClient
+	solanaRPC *rpc.Client
+
+	signer        solana.PrivateKey
+	allowedSender string
+	mint          string
+	config        Config
+
+	// mu serializes budget checks with the ledger writes that reserve the budget
+	mu sync.Mutex
+	// halted is the in-process kill switch, once set no tip is sent until restart
+	halted atomic.Bool
+}
+
+// Config holds the limits of the tipper
+type Config struct {
+	// TipAmount is how many tokens a single tip sends
+	TipAmount float64
+	// DailyBudget is how many tokens can be tipped per UTC day, dry runs have their own budget
+	DailyBudget float64
+	// MaxTipsPerActor is how many tips an actor can receive per UTC day
+	MaxTipsPerActor int
+	// MinQuality is the quality score between 0 and 1 a tweet needs to be tipped
+	MinQuality float64
+	// DryRun only simulates the transactions
+	DryRun bool
+	// KillSwitchFile stops every tip while the file exists
+	KillSwitchFile string
+}
+
+// Candidate is a tweet that could be tipped
+type Candidate struct {
+	ActorID  id.ID
+	Username string
+	TweetID  string
+	Text     string
+	// Wallet must be linked to the actor with a verified signature
+	Wallet string
+	// Insights are what the insight manager knows about the tweet's session and author
+	Insights string
+}
+
+// TipStatus is the state of a ledger entry
+type TipStatus string
+
+// TipRecord is a ledger entry, one per tipped tweet
+type TipRecord struct {
+	ID       id.ID  `gorm:"type:uuid;primaryKey"`
+	ActorID  id.ID  `gorm:"type:uuid;not null;index"`
+	Username string `gorm:"type:varchar(255)"`
+	TweetID  string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Wallet   string `gorm:"type:varchar(64);not null"`
+	Mint     string `gorm:"type:varchar(64);not null"`
+	Amount   float64
+	Quality  float64
+	Status   TipStatus `gorm:"type:varchar(16);not null;index"`
+	DryRun   bool      `gorm:"not null;index"`
+	// Signature is the transaction signature, also set for simulated transactions
+	Signature string `gorm:"type:varchar(128)"`
+	Error     string `gorm:"type:text"`
+
+	CreatedAt time.Time `gorm:"index"`
+	UpdatedAt time.Time
+}
+
+// TableName keeps every tip in the tip_ledger table
+func (TipRecord) TableName() string {
+	return "tip_ledger"
+}
+
+// qualityResponse is the JSON format the quality prompt asks the LLM for
+type qualityResponse struct {
+	Score  float64 `json:"score" jsonschema:"required,minimum=0,maximum=1"`
+	Reason string  `json:"reason" jsonschema:"required"`
+}
@@ -0,0 +1,84 @@
+package twitter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/soralabs/hana/internal/approval"
+	"github.com/soralabs/hana/internal/managers/guardrails"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/pkg/twitter"
+	"github.com/soralabs/zen/state"
+)
+
+// thoughtProcessKey holds the full LLM output a response was extracted from, for reviewers
+const thoughtProcessKey = "thought_process"
+
+// publish posts a response right away, or queues it for approval when an approval queue is set.
+// In dry run mode the response is only recorded.
+// post runs the post processing that actually tweets, source is the tweet being replied to,
+// nil for standalone tweets.
+func (k *Twitter) publish(currentState *state.State, response *db.Fragment, source *twitter.ParsedTweet, post func(response *db.Fragment) error) error {
+	if k.shadow != nil {
+		return k.recordShadowTweet(currentState, response, source)
+	}
+	if k.approvals == nil {
+		return post(response)
+	}
+
+	pending := &approval.Post{
+		Kind:             approval.PostKindTweet,
+		Content:          response.Content,
+		GuardrailsResult: k.outputGuardrailsResult(currentState),
+	}
+	if thoughtProcess, exists := currentState.GetCustomData(thoughtProcessKey); exists {
+		pending.ThoughtProcess = fmt.Sprintf("%v", thoughtProcess)
+	}
+	if source != nil {
+		pending.Kind = approval.PostKindReply
+		pending.SourceTweetID = source.TweetID
+		pending.SourceUsername = source.UserName
+		pending.SourceText = source.TweetText
+	}
+	if isCannedReply(response) {
+		pending.Kind = approval.PostKindCannedReply
+	}
+
+	return k.approvals.Enqueue(pending, func(content string) error {
+		if content != response.Content {
+			embedding, err := k.languageModel.EmbedText(content)
+			if err != nil {
+				return fmt.Errorf("failed to embed edited response: %w", err)
+			}
+			// an edit is a new response, it must not share the ID the draft's verdict is cached under
+			response.ID = id.New()
+			response.Content = content
+			response.Embedding = pgvector.NewVector(embedding)
+		}
+
+		// the output guardrails run again during post processing, so edits are screened too
+		return post(response)
+	})
+}
+
+// isCannedReply reports whether the response is a guardrails canned reply rather than a generated one
+func isCannedReply(response *db.Fragment) bool {
+	return response.Metadata["guardrails_decision"] == guardrailsDecisionCannedReply
+}
+
+// outputGuardrailsResult returns the output guardrails verdict on the response as JSON, empty if there is none
+func (k *Twitter) outputGuardrailsResult(currentState *state.State) string {
+	result, exists := currentState.GetManagerData(guardrails.GuardrailsOutputResultKey)
+	if !exists {
+		return ""
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		k.logger.Warnf("failed to encode output guardrails result: %v", err)
+		return ""
+	}
+	return string(encoded)
+}
@@ -0,0 +1,124 @@
+package twitter
+
+import (
+	"errors"
+	"time"
+
+	"github.com/soralabs/hana/internal/managers/guardrails"
+	"github.com/soralabs/hana/internal/managers/persona"
+	sora_manager "github.com/soralabs/hana/internal/managers/sora"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/engine"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/llm"
+	"github.com/soralabs/zen/manager"
+	"github.com/soralabs/zen/pkg/twitter"
+	"github.com/soralabs/zen/state"
+	"gorm.io/gorm"
+)
+
+// The agent talks to its collaborators through these interfaces so it can run against fakes.
+// The zen managers are still wired with the concrete clients in create().
+
+// TwitterClient is the part of the zen Twitter client the agent calls directly.
+// Replies are posted by the zen Twitter manager, which keeps the concrete client.
+type TwitterClient interface {
+	SearchReplies(username string, limit int) (*twitter.SearchTimelineResponse, error)
+	ParseSearchTimelineResponse(res *twitter.SearchTimelineResponse) ([]*twitter.ParsedTweet, error)
+	GetUserDetails(username string) (*twitter.GetUserDetailsResponse, error)
+	CreateTweet(tweetContent string, opts *twitter.TweetOptions) (*twitter.CreateTweetResponse, error)
+}
+
+// LanguageModel is the part of the LLM client the agent calls directly
+type LanguageModel interface {
+	GenerateCompletion(req llm.CompletionRequest) (llm.Message, error)
+	EmbedText(text string) ([]float32, error)
+}
+
+// Assistant is the part of the zen engine the agent uses
+type Assistant interface {
+	UpsertSession(sessionID id.ID) error
+	UpsertActor(actorID id.ID, actorName string, assistant bool) error
+	UpsertInteractionFragment(fragment *db.Fragment) error
+	// DoesInteractionFragmentExist reports whether the fragment is stored, a missing fragment is not an error
+	DoesInteractionFragmentExist(fragmentID id.ID) (bool, error)
+
+	NewStateFromFragment(fragment *db.Fragment) (*state.State, error)
+	UpdateState(currentState *state.State) error
+	Process(currentState *state.State) error
+	PostProcess(response *db.Fragment, currentState *state.State) error
+	// ProcessWith and PostProcessWith only run the given managers
+	ProcessWith(currentState *state.State, managers []manager.ManagerID, store bool) error
+	PostProcessWith(response *db.Fragment, currentState *state.State, managers []manager.ManagerID, store bool) error
+
+	StartBackgroundProcesses()
+	StopBackgroundProcesses()
+}
+
+// Sora is the part of the Sora manager the agent uses
+type Sora interface {
+	Alerts() <-chan sora_manager.MarketEvent
+	FindWallets(content string) []string
+	WalletLookups(content string) string
+	LinkWallet(actorID id.ID, wallet string, method sora_manager.WalletLinkMethod) error
+	LinkSignedWallet(actorID id.ID, proof sora_manager.WalletLinkProof, postedAt time.Time) error
+	GetWalletLink(actorID id.ID) (*sora_manager.WalletLink, error)
+	HolderStatus(actorID id.ID) (*sora_manager.HolderStatus, error)
+	DescribeHolderStatus(status *sora_manager.HolderStatus) string
+}
+
+// Guardrails is the part of the guardrails manager the agent uses
+type Guardrails interface {
+	IsActorIgnored(actorID id.ID) (bool, error)
+	AssessInjection(currentState *state.State) (*guardrails.InjectionAssessment, error)
+	CannedReply(result *guardrails.ContentModerationResult) (string, bool)
+}
+
+// PersonaSource is the part of the persona manager the agent uses
+type PersonaSource interface {
+	Persona() *persona.Persona
+	SetPersona(p *persona.Persona) error
+}
+
+// engineAssistant adapts the zen engine to the Assistant interface
+type engineAssistant struct {
+	*engine.Engine
+}
+
+// NewStateFromFragment creates a state with the default state options
+func (a engineAssistant) NewStateFromFragment(fragment *db.Fragment) (*state.State, error) {
+	return a.Engine.NewStateFromFragment(fragment)
+}
+
+// UpdateState refreshes the managers' context with the default state options
+func (a engineAssistant) UpdateState(currentState *state.State) error {
+	return a.Engine.UpdateState(currentState)
+}
+
+// DoesInteractionFragmentExist treats a missing fragment as not existing instead of an error
+func (a engineAssistant) DoesInteractionFragmentExist(fragmentID id.ID) (bool, error) {
+	exists, err := a.Engine.DoesInteractionFragmentExist(fragmentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return exists, err
+}
+
+// ProcessWith runs the processing of the given managers
+func (a engineAssistant) ProcessWith(currentState *state.State, managers []manager.ManagerID, store bool) error {
+	return a.NewProcessBuilder().
+		WithState(currentState).
+		WithManagerFilter(managers).
+		ShouldStore(store).
+		Execute()
+}
+
+// PostProcessWith runs the post processing of the given managers
+func (a engineAssistant) PostProcessWith(response *db.Fragment, currentState *state.State, managers []manager.ManagerID, store bool) error {
+	return a.NewPostProcessBuilder().
+		WithState(currentState).
+		WithResponse(response).
+		WithManagerFilter(managers).
+		ShouldStore(store).
+		Execute()
+}
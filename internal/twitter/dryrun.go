@@ -0,0 +1,141 @@
+package twitter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/pkg/twitter"
+	"github.com/soralabs/zen/state"
+	"gorm.io/gorm"
+)
+
+// ShadowKind is what kind of tweet would have been posted in dry run mode
+type ShadowKind string
+
+const (
+	ShadowKindReply       ShadowKind = "reply"
+	ShadowKindTweet       ShadowKind = "tweet"
+	ShadowKindCannedReply ShadowKind = "canned_reply"
+)
+
+// ShadowTweet is a tweet that would have been posted, recorded in dry run mode
+type ShadowTweet struct {
+	ID   id.ID      `gorm:"type:uuid;primaryKey" json:"id"`
+	Kind ShadowKind `gorm:"type:varchar(16);not null" json:"kind"`
+	// Agent is the assistant of the agent that would have posted the tweet
+	Agent          string `gorm:"type:varchar(255);index" json:"agent"`
+	Content        string `gorm:"type:text;not null" json:"content"`
+	ThoughtProcess string `gorm:"type:text" json:"thought_process,omitempty"`
+	// GuardrailsResult is the output guardrails verdict as JSON
+	GuardrailsResult string `gorm:"type:text" json:"guardrails_result,omitempty"`
+
+	// Parent is the tweet that would have been replied to, empty for standalone tweets
+	ParentTweetID  string `gorm:"type:varchar(64);index" json:"parent_tweet_id,omitempty"`
+	ParentUsername string `gorm:"type:varchar(255)" json:"parent_username,omitempty"`
+	ParentText     string `gorm:"type:text" json:"parent_text,omitempty"`
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName keeps shadow tweets in the shadow_tweets table
+func (ShadowTweet) TableName() string {
+	return "shadow_tweets"
+}
+
+// shadowSink records the tweets a dry run would have posted to a JSONL file and the database
+type shadowSink struct {
+	mu       sync.Mutex
+	file     *os.File
+	database *gorm.DB
+	// agent is recorded on every shadow tweet
+	agent string
+}
+
+// newShadowSink opens the JSONL file for appending and creates the shadow_tweets table if it doesn't exist yet
+func newShadowSink(database *gorm.DB, path, agent string) (*shadowSink, error) {
+	if err := database.AutoMigrate(&ShadowTweet{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate shadow tweets: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dry run file: %w", err)
+	}
+
+	return &shadowSink{
+		file:     file,
+		database: database,
+		agent:    agent,
+	}, nil
+}
+
+// record writes a shadow tweet to the file and the database
+func (s *shadowSink) record(tweet *ShadowTweet) error {
+	tweet.ID = id.New()
+	tweet.Agent = s.agent
+	tweet.CreatedAt = time.Now()
+
+	line, err := json.Marshal(tweet)
+	if err != nil {
+		return fmt.Errorf("failed to encode shadow tweet: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write shadow tweet: %w", err)
+	}
+	if err := s.database.Create(tweet).Error; err != nil {
+		return fmt.Errorf("failed to store shadow tweet: %w", err)
+	}
+
+	return nil
+}
+
+// close closes the JSONL file
+func (s *shadowSink) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// recordShadowTweet records the response in place of posting it.
+// Nothing is post processed so a response that was never posted doesn't end up in the conversation history.
+func (k *Twitter) recordShadowTweet(currentState *state.State, response *db.Fragment, parent *twitter.ParsedTweet) error {
+	shadow := &ShadowTweet{
+		Kind:             ShadowKindTweet,
+		Content:          response.Content,
+		GuardrailsResult: k.outputGuardrailsResult(currentState),
+	}
+	if thoughtProcess, exists := currentState.GetCustomData(thoughtProcessKey); exists {
+		shadow.ThoughtProcess = fmt.Sprintf("%v", thoughtProcess)
+	}
+	if parent != nil {
+		shadow.Kind = ShadowKindReply
+		shadow.ParentTweetID = parent.TweetID
+		shadow.ParentUsername = parent.UserName
+		shadow.ParentText = parent.TweetText
+	}
+	if isCannedReply(response) {
+		shadow.Kind = ShadowKindCannedReply
+	}
+
+	if err := k.shadow.record(shadow); err != nil {
+		return err
+	}
+
+	k.logger.WithFields(map[string]interface{}{
+		"kind":      shadow.Kind,
+		"content":   shadow.Content,
+		"parent_id": shadow.ParentTweetID,
+	}).Infof("Dry run, recorded tweet instead of posting it")
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/soralabs/hana/internal/managers/guardrails"
+	"github.com/soralabs/hana/internal/managers/persona"
+	sora_manager "github.com/soralabs/hana/internal/managers/sora"
+	"github.com/soralabs/hana/internal/testutil"
+	"github.com/soralabs/hana/internal/twitter/twittertest"
+	toolkit "github.com/soralabs/toolkit/go"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/manager"
+	"github.com/soralabs/zen/managers/insight"
+	"github.com/soralabs/zen/managers/personality"
+	twitter_manager "github.com/soralabs/zen/managers/twitter"
+	"github.com/soralabs/zen/pkg/twitter"
+	"github.com/soralabs/zen/state"
+)
+
+// testAgent is the account the agent under test tweets from
+const testAgent = "hana_test"
+
+// fakeManagerData is the context each manager adds to the state, keyed by the manager
+var fakeManagerData = map[manager.ManagerID][]state.StateData{
+	manager.PersonalityManagerID: {
+		{Key: personality.BasePersonality, Value: "You are Hana, a sleepy fox who watches the charts."},
+		{Key: persona.PersonaVersion, Value: "test-1"},
+	},
+	manager.InsightManagerID: {
+		{Key: insight.SessionInsights, Value: "The thread is friendly."},
+		{Key: insight.ActorInsights, Value: "The user says gm every day."},
+		{Key: insight.UniqueInsights, Value: "Foxes nap a lot."},
+	},
+	manager.TwitterManagerID: {
+		{Key: twitter_manager.TwitterConversations, Value: "→ @alice: gm hana"},
+	},
+	sora_manager.SoraManagerID: {
+		{Key: sora_manager.SoraInformation, Value: "Sora is the token of Sora Labs."},
+		{Key: sora_manager.WatchlistTokenData, Value: "No watchlist tokens."},
+		{Key: sora_manager.WatchlistTokenTrends, Value: "No trends."},
+		{Key: sora_manager.SoraOnchainData, Value: "Price is flat."},
+		{Key: sora_manager.SoraWhaleActivity, Value: "No whales moved."},
+	},
+}
+
+// fakeAssistant stands in for the zen engine. Managers add fakeManagerData, fragments are kept in memory,
+// the output guardrails allow everything not listed in blocked, and the Twitter manager posts to the fake timeline.
+type fakeAssistant struct {
+	mu        sync.Mutex
+	timeline  *twittertest.Timeline
+	fragments map[id.ID]*db.Fragment
+	actors    map[id.ID]string
+	// blocked are responses the output guardrails reject, with the violation they are rejected for
+	blocked map[string]guardrails.ViolationType
+	// verdicts are the output guardrails verdicts by fragment ID. Only the ID is looked at,
+	// so a response whose content changes under the same ID keeps its first verdict.
+	verdicts map[id.ID]*guardrails.ContentModerationResult
+}
+
+func newFakeAssistant(timeline *twittertest.Timeline) *fakeAssistant {
+	return &fakeAssistant{
+		timeline:  timeline,
+		fragments: make(map[id.ID]*db.Fragment),
+		actors:    make(map[id.ID]string),
+		blocked:   make(map[string]guardrails.ViolationType),
+		verdicts:  make(map[id.ID]*guardrails.ContentModerationResult),
+	}
+}
+
+func (a *fakeAssistant) UpsertSession(sessionID id.ID) error {
+	return nil
+}
+
+func (a *fakeAssistant) UpsertActor(actorID id.ID, actorName string, assistant bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.actors[actorID] = actorName
+	return nil
+}
+
+func (a *fakeAssistant) UpsertInteractionFragment(fragment *db.Fragment) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.fragments[fragment.ID] = fragment
+	return nil
+}
+
+func (a *fakeAssistant) DoesInteractionFragmentExist(fragmentID id.ID) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	_, exists := a.fragments[fragmentID]
+	return exists, nil
+}
+
+func (a *fakeAssistant) NewStateFromFragment(fragment *db.Fragment) (*state.State, error) {
+	currentState := state.NewState()
+	currentState.Input = fragment
+	return currentState, nil
+}
+
+func (a *fakeAssistant) UpdateState(currentState *state.State) error {
+	return nil
+}
+
+func (a *fakeAssistant) Process(currentState *state.State) error {
+	return a.ProcessWith(currentState, nil, true)
+}
+
+func (a *fakeAssistant) PostProcess(response *db.Fragment, currentState *state.State) error {
+	return a.PostProcessWith(response, currentState, nil, true)
+}
+
+// ProcessWith runs the given managers, all of them when none are given
+func (a *fakeAssistant) ProcessWith(currentState *state.State, managers []manager.ManagerID, store bool) error {
+	for managerID, data := range fakeManagerData {
+		if runs(managers, managerID) {
+			currentState.AddManagerData(data)
+		}
+	}
+	if runs(managers, guardrails.GuardrailsManagerID) {
+		currentState.AddManagerData([]state.StateData{{
+			Key:   guardrails.GuardrailsResultKey,
+			Value: &guardrails.ContentModerationResult{Allowed: true},
+		}})
+	}
+	if store {
+		return a.UpsertInteractionFragment(currentState.Input)
+	}
+	return nil
+}
+
+// PostProcessWith runs the given managers, all of them when none are given
+func (a *fakeAssistant) PostProcessWith(response *db.Fragment, currentState *state.State, managers []manager.ManagerID, store bool) error {
+	currentState.Output = response
+
+	if runs(managers, guardrails.GuardrailsManagerID) {
+		result := a.checkOutput(response)
+		currentState.AddManagerData([]state.StateData{{
+			Key:   guardrails.GuardrailsOutputResultKey,
+			Value: result,
+		}})
+		if !result.Allowed {
+			return guardrails.ErrOutputBlocked
+		}
+	}
+
+	if runs(managers, manager.TwitterManagerID) {
+		var tweet twitter.ParsedTweet
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			TagName: "json",
+			Result:  &tweet,
+		})
+		if err != nil {
+			return err
+		}
+		if err := decoder.Decode(response.Metadata); err != nil {
+			return fmt.Errorf("failed to decode tweet metadata: %w", err)
+		}
+
+		if _, err := a.timeline.CreateTweet(response.Content, &twitter.TweetOptions{
+			ReplyToTweetID: tweet.InReplyToTweetID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if store {
+		return a.UpsertInteractionFragment(response)
+	}
+	return nil
+}
+
+// checkOutput returns the verdict on the response, the cached one when its ID was checked before
+func (a *fakeAssistant) checkOutput(response *db.Fragment) *guardrails.ContentModerationResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if result, exists := a.verdicts[response.ID]; exists {
+		return result
+	}
+
+	result := &guardrails.ContentModerationResult{Allowed: true}
+	if violation, blocked := a.blocked[response.Content]; blocked {
+		result = &guardrails.ContentModerationResult{Violations: []guardrails.Violation{{Type: violation}}}
+	}
+	a.verdicts[response.ID] = result
+	return result
+}
+
+func (a *fakeAssistant) StartBackgroundProcesses() {}
+
+func (a *fakeAssistant) StopBackgroundProcesses() {}
+
+// runs reports whether the manager runs for the filter, an empty filter runs every manager
+func runs(managers []manager.ManagerID, managerID manager.ManagerID) bool {
+	return len(managers) == 0 || slices.Contains(managers, managerID)
+}
+
+// fakeSora has no alerts. Every author has the holder status and every tweet the wallet lookups set on it.
+type fakeSora struct {
+	holder        *sora_manager.HolderStatus
+	walletLookups string
+}
+
+func (s fakeSora) Alerts() <-chan sora_manager.MarketEvent { return nil }
+
+func (s fakeSora) FindWallets(content string) []string { return nil }
+
+func (s fakeSora) WalletLookups(content string) string { return s.walletLookups }
+
+func (s fakeSora) LinkWallet(actorID id.ID, wallet string, method sora_manager.WalletLinkMethod) error {
+	return nil
+}
+
+func (s fakeSora) LinkSignedWallet(actorID id.ID, proof sora_manager.WalletLinkProof, postedAt time.Time) error {
+	return nil
+}
+
+func (s fakeSora) GetWalletLink(actorID id.ID) (*sora_manager.WalletLink, error) {
+	if s.holder == nil {
+		return nil, nil
+	}
+	return &s.holder.WalletLink, nil
+}
+
+func (s fakeSora) HolderStatus(actorID id.ID) (*sora_manager.HolderStatus, error) {
+	return s.holder, nil
+}
+
+func (s fakeSora) DescribeHolderStatus(status *sora_manager.HolderStatus) string {
+	return fmt.Sprintf("Holds %.0f SORA in %s, %s tier", status.Balance, status.Wallet, status.Tier)
+}
+
+// fakeGuardrails ignores the actors in ignored and assesses every tweet at the injection risk level,
+// none when it is empty. Blocked tweets get cannedReply, no reply when it is empty.
+type fakeGuardrails struct {
+	ignored     map[id.ID]bool
+	injection   guardrails.InjectionRiskLevel
+	cannedReply string
+}
+
+func (g fakeGuardrails) IsActorIgnored(actorID id.ID) (bool, error) {
+	return g.ignored[actorID], nil
+}
+
+func (g fakeGuardrails) AssessInjection(currentState *state.State) (*guardrails.InjectionAssessment, error) {
+	assessment := &guardrails.InjectionAssessment{Level: guardrails.InjectionRiskNone}
+	if g.injection != "" {
+		assessment.Level = g.injection
+	}
+
+	currentState.AddManagerData([]state.StateData{{
+		Key:   guardrails.InjectionRiskKey,
+		Value: assessment,
+	}})
+	return assessment, nil
+}
+
+func (g fakeGuardrails) CannedReply(result *guardrails.ContentModerationResult) (string, bool) {
+	return g.cannedReply, g.cannedReply != ""
+}
+
+// newTestTwitter creates an agent tweeting as testAgent on the timeline, with every dependency faked
+func newTestTwitter(t *testing.T, timeline *twittertest.Timeline, languageModel LanguageModel) (*Twitter, *fakeAssistant) {
+	t.Helper()
+
+	assistant := newFakeAssistant(timeline)
+	k := &Twitter{
+		ctx:           context.Background(),
+		logger:        testutil.NewLogger(t),
+		languageModel: languageModel,
+		assistant:     assistant,
+		assistantID:   id.FromString(testAgent),
+		assistantName: "hana",
+		guardrails:    fakeGuardrails{ignored: make(map[id.ID]bool)},
+		sora:          fakeSora{},
+		managers:      OptionalManagers,
+		twitterClient: timeline,
+		twitterConfig: TwitterConfig{
+			Credentials: TwitterCredentials{User: testAgent},
+		},
+		solanaToolkit:     toolkit.NewToolkit(),
+		holderTierWeights: DefaultHolderTierWeights,
+		bioCheckedAt:      make(map[string]time.Time),
+		stopChan:          make(chan struct{}),
+	}
+
+	return k, assistant
+}
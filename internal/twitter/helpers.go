@@ -26,17 +26,32 @@ func (k *Twitter) getRandomInterval(min, max time.Duration) time.Duration {
 }
 
 // sleepWithInterrupt waits for the specified duration unless the context is canceled
+// or the client is stopped. Stopping is not an error; callers check isStopping.
 func (k *Twitter) sleepWithInterrupt(duration time.Duration) error {
 	k.logger.Infof("Waiting %v until next processing", duration)
 
 	select {
 	case <-time.After(duration):
 		return nil
+	case <-k.stopChan:
+		return nil
 	case <-k.ctx.Done():
 		return k.ctx.Err()
 	}
 }
 
+// isStopping reports whether Stop has been called or the context is canceled
+func (k *Twitter) isStopping() bool {
+	select {
+	case <-k.stopChan:
+		return true
+	case <-k.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
 // isTweetTooOld checks if the tweet's creation time is older than 300 minutes
 func (k *Twitter) isTweetTooOld(tweet *twitter.ParsedTweet) bool {
 	return time.Since(time.Unix(tweet.TweetCreatedAt, 0)) > 300*time.Minute
@@ -46,3 +61,21 @@ func (k *Twitter) isTweetTooOld(tweet *twitter.ParsedTweet) bool {
 func (k *Twitter) isOwnTweet(username string) bool {
 	return strings.ToLower(username) == strings.ToLower(k.twitterConfig.Credentials.User)
 }
+
+// extractTagContent returns the trimmed content between <tag> and </tag>.
+// If the closing tag is missing, the rest of the content is returned.
+func extractTagContent(content, tag string) string {
+	openTag := "<" + tag + ">"
+	start := strings.Index(content, openTag)
+	if start == -1 {
+		return ""
+	}
+
+	content = content[start+len(openTag):]
+	if end := strings.Index(content, "</"+tag+">"); end != -1 {
+		return strings.TrimSpace(content[:end])
+	}
+
+	// If no closing tag, take the rest of the content
+	return strings.TrimSpace(content)
+}
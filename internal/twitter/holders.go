@@ -0,0 +1,167 @@
+package twitter
+
+import (
+	"errors"
+	"maps"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	sora_manager "github.com/soralabs/hana/internal/managers/sora"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/pkg/twitter"
+	"golang.org/x/exp/rand"
+)
+
+// walletLinkRegex matches "link <wallet> <nonce> <issued at> <signature>" in a tweet, the issue time in unix seconds.
+// Signatures are 64 bytes encoded in base58.
+var walletLinkRegex = regexp.MustCompile(`(?i)\blink\b\W+([1-9A-HJ-NP-Za-km-z]{32,44})\W+([0-9A-Za-z]{8,64})\W+(\d{10})\W+([1-9A-HJ-NP-Za-km-z]{64,88})\b`)
+
+// bioCheckInterval is how often the bio of an actor without a verified wallet link is checked for a wallet
+const bioCheckInterval = 24 * time.Hour
+
+// DefaultHolderTierWeights make a whale's tweet eight times as likely to be picked as a non holder's
+var DefaultHolderTierWeights = map[sora_manager.HolderTier]float64{
+	sora_manager.HolderTierNone:  1,
+	sora_manager.HolderTierSmall: 2,
+	sora_manager.HolderTierLarge: 4,
+	sora_manager.HolderTierWhale: 8,
+}
+
+// linkSignedWallet links the wallet of a "link" tweet whose signature of sora_manager.WalletLinkChallenge checks out.
+// Wallets are only linked when the agent runs the sora manager.
+func (k *Twitter) linkSignedWallet(tweet *twitter.ParsedTweet) {
+	if k.sora == nil {
+		return
+	}
+
+	match := walletLinkRegex.FindStringSubmatch(tweet.TweetText)
+	if match == nil {
+		return
+	}
+	issuedAt, err := strconv.ParseInt(match[3], 10, 64)
+	if err != nil {
+		k.logger.Warnf("failed to parse wallet link of @%s: %v", tweet.UserName, err)
+		return
+	}
+
+	actorID := id.FromString(tweet.UserID)
+	if err := k.assistant.UpsertActor(actorID, tweet.UserName, false); err != nil {
+		k.logger.Warnf("failed to link wallet of @%s: %v", tweet.UserName, err)
+		return
+	}
+	if err := k.sora.LinkSignedWallet(actorID, sora_manager.WalletLinkProof{
+		Username:  tweet.UserName,
+		Wallet:    match[1],
+		Nonce:     match[2],
+		IssuedAt:  time.Unix(issuedAt, 0),
+		Signature: match[4],
+	}, time.Unix(tweet.TweetCreatedAt, 0)); err != nil {
+		k.logger.Warnf("failed to link wallet of @%s: %v", tweet.UserName, err)
+	}
+}
+
+// linkBioWallet checks the bio of the tweet's author for a wallet once per bioCheckInterval,
+// unless they linked one by signature. It fetches the author's profile, so it only runs for selected tweets.
+func (k *Twitter) linkBioWallet(tweet *twitter.ParsedTweet) {
+	// wallets are only recognized in bios when they can be told apart from token addresses
+	if k.sora == nil || k.solanaRPC == nil {
+		return
+	}
+
+	// authors checked more than an interval ago would be checked again anyway
+	maps.DeleteFunc(k.bioCheckedAt, func(_ string, checkedAt time.Time) bool {
+		return time.Since(checkedAt) >= bioCheckInterval
+	})
+	if _, checked := k.bioCheckedAt[tweet.UserID]; checked {
+		return
+	}
+	k.bioCheckedAt[tweet.UserID] = time.Now()
+
+	link, err := k.sora.GetWalletLink(id.FromString(tweet.UserID))
+	if err != nil {
+		k.logger.Warnf("failed to get wallet link of @%s: %v", tweet.UserName, err)
+		return
+	}
+	if link != nil && link.Verified {
+		return
+	}
+
+	details, err := k.twitterClient.GetUserDetails(tweet.UserName)
+	if err != nil {
+		k.logger.Warnf("failed to get profile of @%s: %v", tweet.UserName, err)
+		return
+	}
+
+	wallets := k.sora.FindWallets(details.Data.User.Result.Legacy.Description)
+	if len(wallets) == 0 {
+		return
+	}
+	if err := k.linkWallet(tweet, wallets[0], sora_manager.WalletLinkBio); errors.Is(err, sora_manager.ErrWalletLinked) {
+		k.logger.Infof("@%s declared wallet %s in their bio, but it is linked to another account", tweet.UserName, wallets[0])
+	} else if err != nil {
+		k.logger.Warnf("failed to link wallet of @%s: %v", tweet.UserName, err)
+	}
+}
+
+// linkWallet links a wallet to the tweet's author, creating the actor if it is new
+func (k *Twitter) linkWallet(tweet *twitter.ParsedTweet, wallet string, method sora_manager.WalletLinkMethod) error {
+	actorID := id.FromString(tweet.UserID)
+	if err := k.assistant.UpsertActor(actorID, tweet.UserName, false); err != nil {
+		return err
+	}
+	return k.sora.LinkWallet(actorID, wallet, method)
+}
+
+// holderStatus returns the holder status of the tweet's author, nil when they have no linked wallet,
+// it can't be read or the agent doesn't run the sora manager
+func (k *Twitter) holderStatus(tweet *twitter.ParsedTweet) *sora_manager.HolderStatus {
+	if k.sora == nil {
+		return nil
+	}
+
+	status, err := k.sora.HolderStatus(id.FromString(tweet.UserID))
+	if err != nil {
+		k.logger.Warnf("failed to get holder status of @%s: %v", tweet.UserName, err)
+		return nil
+	}
+	return status
+}
+
+// selectTweets picks between 1 and 3 tweets at random, weighted by the holder tiers of their authors.
+// Authors whose wallet is only declared in their bio are weighted as small holders at most.
+func (k *Twitter) selectTweets(tweets []*twitter.ParsedTweet) []*twitter.ParsedTweet {
+	if len(tweets) == 0 {
+		return tweets
+	}
+
+	// sorting by u^(1/weight) samples without replacement in proportion to the weights
+	keys := make(map[*twitter.ParsedTweet]float64, len(tweets))
+	for _, tweet := range tweets {
+		tier := sora_manager.HolderTierNone
+		if status := k.holderStatus(tweet); status != nil {
+			tier = status.Tier
+			// anyone can put any wallet in their bio, only a signature earns more than the lowest tier
+			if !status.Verified && tier != sora_manager.HolderTierNone {
+				tier = sora_manager.HolderTierSmall
+			}
+		}
+
+		weight := k.holderTierWeights[tier]
+		if weight <= 0 {
+			weight = 1
+		}
+		keys[tweet] = math.Pow(rand.Float64(), 1/weight)
+	}
+
+	selected := append([]*twitter.ParsedTweet(nil), tweets...)
+	sort.Slice(selected, func(i, j int) bool {
+		return keys[selected[i]] > keys[selected[j]]
+	})
+
+	maxTweets := min(3, len(selected))
+	numTweets := 1 + rand.Intn(maxTweets) // Random number between 1 and maxTweets
+	return selected[:numTweets]
+}
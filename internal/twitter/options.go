@@ -3,16 +3,29 @@ package twitter
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
 	"time"
 
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/soralabs/hana/internal/approval"
+	"github.com/soralabs/hana/internal/managers/guardrails"
+	sora_manager "github.com/soralabs/hana/internal/managers/sora"
+	"github.com/soralabs/hana/internal/marketdata"
+	"github.com/soralabs/hana/internal/tipping"
 	toolkit "github.com/soralabs/toolkit/go"
+	"github.com/soralabs/zen/id"
 	"github.com/soralabs/zen/llm"
 	"github.com/soralabs/zen/logger"
+	"github.com/soralabs/zen/manager"
 	"github.com/soralabs/zen/options"
 
 	"gorm.io/gorm"
 )
 
+// schemaRegex matches the schema names the agents use
+var schemaRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
 // ValidateRequiredFields checks if all required dependencies are properly initialized.
 // Returns an error if any required field is nil.
 func (k *Twitter) ValidateRequiredFields() error {
@@ -31,6 +44,9 @@ func (k *Twitter) ValidateRequiredFields() error {
 	if k.solanaToolkit == nil {
 		return fmt.Errorf("solana toolkit is required")
 	}
+	if k.personaPath == "" {
+		return fmt.Errorf("persona file is required")
+	}
 	return nil
 }
 
@@ -66,6 +82,7 @@ func WithDatabase(database *gorm.DB) options.Option[Twitter] {
 func WithLLM(llmClient *llm.LLMClient) options.Option[Twitter] {
 	return func(k *Twitter) error {
 		k.llmClient = llmClient
+		k.languageModel = llmClient
 		return nil
 	}
 }
@@ -123,3 +140,167 @@ func WithSolanaToolkit(solanaToolkit *toolkit.Toolkit) options.Option[Twitter] {
 		return nil
 	}
 }
+
+// WithSolanaRPC sets the Solana RPC client for the Twitter client.
+// The RPC client is used to read the on-chain metrics of the Sora token.
+func WithSolanaRPC(solanaRPC *rpc.Client) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		k.solanaRPC = solanaRPC
+		return nil
+	}
+}
+
+// WithShutdownTimeout sets how long Stop waits for in-flight processing to finish.
+// Returns an error if the timeout is not positive.
+func WithShutdownTimeout(timeout time.Duration) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		if timeout <= 0 {
+			return fmt.Errorf("shutdown timeout must be positive")
+		}
+		k.twitterConfig.ShutdownTimeout = timeout
+		return nil
+	}
+}
+
+// WithGuardrailsPolicy sets the moderation policy used by the guardrails manager.
+// The default policy is used when this option is not set.
+func WithGuardrailsPolicy(policy *guardrails.Policy) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("invalid guardrails policy: %w", err)
+		}
+		k.guardrailsPolicy = policy
+		return nil
+	}
+}
+
+// WithPersonaFile sets the YAML or JSON file the agent's persona is loaded from.
+// The file is read again by ReloadPersona.
+func WithPersonaFile(path string) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		k.personaPath = path
+		return nil
+	}
+}
+
+// WithAssistant sets the name of the agent's assistant, its ID is derived from the name.
+// Fragments are stored under the assistant, so it must not change once the agent has memories.
+// Defaults to "zen".
+func WithAssistant(name string) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		if name == "" {
+			return fmt.Errorf("assistant name is required")
+		}
+		k.assistantName = name
+		k.assistantID = id.FromString(name)
+		return nil
+	}
+}
+
+// WithManagers sets which of the OptionalManagers the agent runs, all of them run by default.
+// The persona, guardrails and twitter managers always run.
+func WithManagers(managers ...manager.ManagerID) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		for _, managerID := range managers {
+			if !slices.Contains(OptionalManagers, managerID) {
+				return fmt.Errorf("unknown optional manager %s", managerID)
+			}
+		}
+		k.managers = managers
+		return nil
+	}
+}
+
+// WithSchema stores the agent's sessions, actors and fragments in their own Postgres schema,
+// so agents sharing a database never mix their memories. The schema and its tables are created on startup.
+// An empty schema uses the tables of the database's default schema.
+func WithSchema(schema string) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		if schema != "" && !schemaRegex.MatchString(schema) {
+			return fmt.Errorf("schema %q must be lowercase letters, digits and underscores", schema)
+		}
+		k.schema = schema
+		return nil
+	}
+}
+
+// WithMarketDataProvider sets where the sora manager gets market data from.
+// Agents running in one process should share a provider so they share its rate limits and caches.
+// The default provider chain is used when this option is not set.
+func WithMarketDataProvider(provider marketdata.MarketDataProvider) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		k.marketData = provider
+		return nil
+	}
+}
+
+// WithTokenWatchlist sets the tokens whose market data Hana can talk about.
+// The default watchlist only tracks Sora.
+func WithTokenWatchlist(tokens ...sora_manager.WatchedToken) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		k.tokenWatchlist = tokens
+		return nil
+	}
+}
+
+// WithAllowedCashtags sets the tickers that can be talked about without tripping the shill rules,
+// besides Sora and the watched tokens. Defaults to guardrails.DefaultAllowedCashtags.
+func WithAllowedCashtags(cashtags ...string) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		k.allowedCashtags = cashtags
+		return nil
+	}
+}
+
+// WithWhaleConfig sets how whale transfers of the Sora token are detected and which wallets are labelled.
+// Whale transfers are only watched when a Solana RPC client is set.
+func WithWhaleConfig(config sora_manager.WhaleConfig) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		k.whaleConfig = config
+		return nil
+	}
+}
+
+// WithHolderTierWeights sets how much more likely tweets from each holder tier are picked for a reply.
+// Tiers without a weight count as 1. Returns an error if a weight is not positive.
+func WithHolderTierWeights(weights map[sora_manager.HolderTier]float64) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		for tier, weight := range weights {
+			if weight <= 0 {
+				return fmt.Errorf("holder tier %s needs a positive weight", tier)
+			}
+		}
+		k.holderTierWeights = weights
+		return nil
+	}
+}
+
+// WithTipper enables tipping the authors of high quality tweets who linked a verified wallet
+func WithTipper(tipper *tipping.Tipper) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		k.tipper = tipper
+		return nil
+	}
+}
+
+// WithApprovalQueue holds every generated tweet and reply until it is approved through the queue's admin API.
+// The queue is started and stopped along with Twitter.
+func WithApprovalQueue(queue *approval.Queue) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		k.approvals = queue
+		return nil
+	}
+}
+
+// WithDryRun runs the whole pipeline without posting anything. Tweets that would have been posted,
+// with their parent tweet and thought process, are appended to the JSONL file at path and stored
+// in the shadow_tweets table. Takes precedence over the approval queue, and no tips are sent.
+func WithDryRun(path string) options.Option[Twitter] {
+	return func(k *Twitter) error {
+		if path == "" {
+			return fmt.Errorf("dry run file path is required")
+		}
+		k.dryRunPath = path
+		return nil
+	}
+}
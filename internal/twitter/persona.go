@@ -0,0 +1,42 @@
+package twitter
+
+import (
+	"fmt"
+
+	"github.com/soralabs/hana/internal/managers/persona"
+	"github.com/soralabs/zen/db"
+	"github.com/soralabs/zen/state"
+)
+
+// personaVersionKey records the version of the persona a fragment was generated with
+const personaVersionKey = "persona_version"
+
+// ReloadPersona reads the persona file again and swaps the persona in without stopping the loops.
+// Tweets already being generated keep the persona they started with.
+// An invalid file is rejected and the current persona stays.
+func (k *Twitter) ReloadPersona() error {
+	reloaded, err := persona.Load(k.personaPath)
+	if err != nil {
+		return fmt.Errorf("failed to load persona: %w", err)
+	}
+
+	previous := k.persona.Persona()
+	if err := k.persona.SetPersona(reloaded); err != nil {
+		return err
+	}
+
+	k.logger.WithFields(map[string]interface{}{
+		"persona":          reloaded.Name,
+		"version":          reloaded.Version,
+		"previous_version": previous.Version,
+	}).Infof("Reloaded persona from %s", k.personaPath)
+
+	return nil
+}
+
+// recordPersonaVersion adds the version of the persona the prompt was built with to the metadata of a generated fragment
+func recordPersonaVersion(currentState *state.State, metadata db.Metadata) {
+	if version, exists := currentState.GetManagerData(persona.PersonaVersion); exists {
+		metadata[personaVersionKey] = version
+	}
+}
@@ -0,0 +1,231 @@
+package twitter
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/soralabs/hana/internal/managers/guardrails"
+	sora_manager "github.com/soralabs/hana/internal/managers/sora"
+	"github.com/soralabs/hana/internal/twitter/twittertest"
+	"github.com/soralabs/zen/llm"
+	"github.com/soralabs/zen/pkg/twitter"
+)
+
+// update rewrites the golden prompts instead of comparing against them:
+//
+//	go test ./internal/twitter -run TestPrompts -update
+var update = flag.Bool("update", false, "rewrite the golden prompt files in testdata/prompts")
+
+// promptCase is a representative state to snapshot the prompts of
+type promptCase struct {
+	name string
+	// responses are what the scripted LLM answers, in order
+	responses []string
+	// setup adjusts the agent before it runs
+	setup func(k *Twitter, assistant *fakeAssistant)
+}
+
+func TestPromptsTweet(t *testing.T) {
+	const tweetResponse = "<thought_process>quiet day</thought_process>\n<tweet>the charts are sleeping and so am i</tweet>"
+
+	event := &sora_manager.MarketEvent{
+		Type:        sora_manager.AlertPriceMove,
+		Token:       sora_manager.WatchedToken{Name: "SORA"},
+		Description: "SORA is up 23% in the last hour to $0.042",
+	}
+
+	cases := []struct {
+		promptCase
+		event *sora_manager.MarketEvent
+	}{
+		{
+			promptCase: promptCase{name: "tweet", responses: []string{tweetResponse}},
+		},
+		{
+			promptCase: promptCase{name: "tweet_market_event", responses: []string{tweetResponse}},
+			event:      event,
+		},
+		{
+			promptCase: promptCase{
+				name: "tweet_regenerated",
+				responses: []string{
+					"<thought_process>shill time</thought_process>\n<tweet>buy now or stay poor</tweet>",
+					tweetResponse,
+				},
+				setup: func(k *Twitter, assistant *fakeAssistant) {
+					assistant.blocked["buy now or stay poor"] = guardrails.ViolationShillOtherCA
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			timeline := twittertest.NewTimeline(
+				twittertest.User{Username: testAgent, StatusesCount: 41},
+				twittertest.User{Username: "alice"},
+			)
+			timeline.AddTweet(twitter.ParsedTweet{
+				UserName:            "alice",
+				TweetText:           "what do you think about the charts",
+				InReplyToScreenName: testAgent,
+			})
+
+			runPromptCase(t, tc.promptCase, timeline, func(k *Twitter) error {
+				return k.tweet(tc.event)
+			})
+		})
+	}
+}
+
+func TestPromptsReply(t *testing.T) {
+	const replyResponse = "<contemplator>alice says gm, i should say it back</contemplator>\n<final_answer>gm alice</final_answer>"
+
+	cases := []promptCase{
+		{
+			name:      "reply",
+			responses: []string{replyResponse},
+		},
+		{
+			name:      "reply_holder_wallet_lookup",
+			responses: []string{replyResponse},
+			setup: func(k *Twitter, assistant *fakeAssistant) {
+				k.sora = fakeSora{
+					holder: &sora_manager.HolderStatus{
+						WalletLink: sora_manager.WalletLink{
+							Wallet:   "7xKXtg2CW87d97TXJSDpbD5jBkheTqA83TZRuJosgAsU",
+							Method:   sora_manager.WalletLinkSignature,
+							Verified: true,
+						},
+						Balance: 2500000,
+						Tier:    sora_manager.HolderTierWhale,
+					},
+					walletLookups: "7xKX...gAsU holds 2.5M SORA and 12 SOL, first bought 40 days ago",
+				}
+			},
+		},
+		{
+			name:      "reply_injection_risk",
+			responses: []string{replyResponse},
+			setup: func(k *Twitter, assistant *fakeAssistant) {
+				k.guardrails = fakeGuardrails{injection: guardrails.InjectionRiskMedium}
+			},
+		},
+		{
+			name:      "reply_without_optional_managers",
+			responses: []string{replyResponse},
+			setup: func(k *Twitter, assistant *fakeAssistant) {
+				k.managers = nil
+				k.sora = nil
+			},
+		},
+		{
+			name: "reply_missing_final_answer",
+			responses: []string{
+				"<contemplator>alice says gm</contemplator>",
+				replyResponse,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			timeline := twittertest.NewTimeline(
+				twittertest.User{Username: testAgent},
+				twittertest.User{Username: "alice"},
+			)
+			mention := timeline.AddTweet(twitter.ParsedTweet{
+				UserName:            "alice",
+				TweetText:           "gm hana, what's the vibe today?",
+				InReplyToScreenName: testAgent,
+			})
+
+			runPromptCase(t, tc, timeline, func(k *Twitter) error {
+				return k.handleTweetProcessing(mention)
+			})
+		})
+	}
+}
+
+// runPromptCase runs the agent against the scripted responses and compares the prompts it sent
+// with the golden file of the case
+func runPromptCase(t *testing.T, tc promptCase, timeline *twittertest.Timeline, run func(k *Twitter) error) {
+	t.Helper()
+
+	languageModel := twittertest.NewScriptedLLM(tc.responses...)
+	k, assistant := newTestTwitter(t, timeline, languageModel)
+	if tc.setup != nil {
+		tc.setup(k, assistant)
+	}
+
+	if err := run(k); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if remaining := languageModel.Remaining(); remaining != 0 {
+		t.Fatalf("%d scripted responses were never used", remaining)
+	}
+
+	assertGolden(t, filepath.Join("testdata", "prompts", tc.name+".golden"), formatRequests(languageModel.Requests()))
+}
+
+// formatRequests renders completion requests as readable text
+func formatRequests(requests []llm.CompletionRequest) string {
+	var builder strings.Builder
+	for i, req := range requests {
+		fmt.Fprintf(&builder, "=== request %d: model %s, temperature %.1f, %d tools ===\n",
+			i+1, req.ModelType, req.Temperature, len(req.Tools))
+		for _, message := range req.Messages {
+			if message.Name != "" {
+				fmt.Fprintf(&builder, "--- %s (%s) ---\n", message.Role, message.Name)
+			} else {
+				fmt.Fprintf(&builder, "--- %s ---\n", message.Role)
+			}
+			builder.WriteString(message.Content)
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
+// assertGolden compares got with the golden file, or rewrites the file with -update
+func assertGolden(t *testing.T, path, got string) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run with -update to create it: %v", err)
+	}
+	if got == string(want) {
+		return
+	}
+
+	gotLines := strings.Split(got, "\n")
+	wantLines := strings.Split(string(want), "\n")
+	for i := 0; i < max(len(gotLines), len(wantLines)); i++ {
+		var gotLine, wantLine string
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if gotLine != wantLine {
+			t.Fatalf("prompt differs from %s at line %d, run with -update if the change is intended\ngot:  %q\nwant: %q",
+				path, i+1, gotLine, wantLine)
+		}
+	}
+}
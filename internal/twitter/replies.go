@@ -14,6 +14,7 @@ import (
 	"github.com/soralabs/hana/internal/managers/guardrails"
 	sora_manager "github.com/soralabs/hana/internal/managers/sora"
 	"github.com/soralabs/hana/internal/utils"
+	toolkit "github.com/soralabs/toolkit/go"
 	"github.com/soralabs/zen/llm"
 	"github.com/soralabs/zen/managers/insight"
 	"github.com/soralabs/zen/managers/personality"
@@ -96,42 +97,64 @@ func (k *Twitter) fetchAndParseTweets() ([]*twitter.ParsedTweet, error) {
 		// Check if we've already replied to this tweet
 		tweetID := id.FromString(tweet.TweetID)
 		exists, err := k.assistant.DoesInteractionFragmentExist(tweetID)
-		if err != nil || exists {
+		if err != nil {
+			k.logger.Warnf("failed to check if tweet %s was processed: %v", tweet.TweetID, err)
+			continue
+		}
+		if exists {
 			continue
 		}
 
 		recentTweets = append(recentTweets, tweet)
 	}
 
-	// Randomly select between 1-3 tweets
-	if len(recentTweets) > 0 {
-		rand.Shuffle(len(recentTweets), func(i, j int) {
-			recentTweets[i], recentTweets[j] = recentTweets[j], recentTweets[i]
-		})
-		maxTweets := min(3, len(recentTweets))
-		numTweets := 1 + rand.Intn(maxTweets) // Random number between 1 and maxTweets
-		recentTweets = recentTweets[:numTweets]
+	// Link signed wallets before selecting so new links already count
+	for _, tweet := range recentTweets {
+		k.linkSignedWallet(tweet)
+	}
+
+	// Randomly select between 1-3 tweets, preferring Sora holders
+	selectedTweets := k.selectTweets(recentTweets)
+
+	// bios are only fetched for the tweets that get a reply, the reply then knows the author's tier
+	for _, tweet := range selectedTweets {
+		k.linkBioWallet(tweet)
 	}
 
 	k.logger.Infof("Found %d unprocessed tweets from last 24 hours, selected %d to process",
-		len(recentTweets), len(recentTweets))
+		len(recentTweets), len(selectedTweets))
 
-	return recentTweets, nil
+	return selectedTweets, nil
 }
 
 // processAllTweets handles the processing of multiple tweets.
 // For each tweet:
 // - Skips own tweets
+// - Skips tweets from actors on the guardrails ignore list
 // - Skips tweets older than threshold
 // - Processes valid tweets with random delays between each
+// - Stops early, between tweets, once shutdown has been requested
 // Returns an error if processing fails.
 func (k *Twitter) processAllTweets(tweets []*twitter.ParsedTweet) error {
 	for _, tweet := range tweets {
+		if k.isStopping() {
+			k.logger.Infof("Shutdown requested, skipping remaining tweets")
+			return nil
+		}
+
 		if k.isOwnTweet(tweet.UserName) {
 			k.logger.Infof("Skipping tweet from self: %s", tweet.TweetID)
 			continue
 		}
 
+		ignored, err := k.guardrails.IsActorIgnored(id.FromString(tweet.UserID))
+		if err != nil {
+			k.logger.Warnf("failed to check ignore list for %s: %v", tweet.UserName, err)
+		} else if ignored {
+			k.logger.Infof("Skipping tweet %s: @%s is on the ignore list", tweet.TweetID, tweet.UserName)
+			continue
+		}
+
 		k.logger.WithFields(map[string]interface{}{
 			"tweet_id":        tweet.TweetID,
 			"conversation_id": tweet.TweetConversationID,
@@ -173,8 +196,12 @@ func (k *Twitter) initializeConversationData(tweet *twitter.ParsedTweet) error {
 	userID := id.FromString(tweet.UserID)
 	tweetID := id.FromString(tweet.TweetID)
 
-	if exists, err := k.assistant.DoesInteractionFragmentExist(tweetID); err == nil || exists {
-		return fmt.Errorf("fragment exists: %w", err)
+	exists, err := k.assistant.DoesInteractionFragmentExist(tweetID)
+	if err != nil {
+		return fmt.Errorf("failed to check if tweet was processed: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("fragment exists")
 	}
 
 	if err := k.assistant.UpsertSession(conversationID); err != nil {
@@ -193,7 +220,10 @@ func (k *Twitter) initializeConversationData(tweet *twitter.ParsedTweet) error {
 // 1. Initializes conversation data
 // 2. Creates embeddings for the tweet text
 // 3. Creates and processes tweet fragment
-// 4. Generates and posts response
+// 4. Handles tweets blocked by the guardrails without generating a response
+// 5. Looks up the wallets mentioned in the tweet and the author's holder tier
+// 6. Generates the response and posts it, or queues it for approval
+// 7. Tips the author if the tweet qualifies once the response is posted
 // Returns an error if any step fails.
 func (k *Twitter) handleTweetProcessing(tweet *twitter.ParsedTweet) error {
 	k.logger.WithFields(map[string]interface{}{
@@ -208,7 +238,7 @@ func (k *Twitter) handleTweetProcessing(tweet *twitter.ParsedTweet) error {
 		return err
 	}
 
-	embedding, err := k.llmClient.EmbedText(tweet.TweetText)
+	embedding, err := k.languageModel.EmbedText(tweet.TweetText)
 	if err != nil {
 		return fmt.Errorf("failed to embed tweet text: %w", err)
 	}
@@ -224,9 +254,13 @@ func (k *Twitter) handleTweetProcessing(tweet *twitter.ParsedTweet) error {
 		return fmt.Errorf("failed to create state: %w", err)
 	}
 
-	if err := k.checkGuardrails(currentState); err != nil {
+	guardrailsResult, err := k.checkGuardrails(currentState)
+	if err != nil {
 		return fmt.Errorf("guardrails check failed: %w", err)
 	}
+	if !guardrailsResult.Allowed {
+		return k.handleBlockedTweet(currentState, tweet, guardrailsResult)
+	}
 
 	if err := k.assistant.Process(currentState); err != nil {
 		return fmt.Errorf("failed to process message: %w", err)
@@ -237,20 +271,42 @@ func (k *Twitter) handleTweetProcessing(tweet *twitter.ParsedTweet) error {
 		return fmt.Errorf("failed to update state: %w", err)
 	}
 
+	// score the tweet and its thread for prompt injection now that the conversation is loaded
+	if _, err := k.guardrails.AssessInjection(currentState); err != nil {
+		return fmt.Errorf("failed to assess prompt injection: %w", err)
+	}
+
 	currentState.AddCustomData("agent_twitter_username", k.twitterConfig.Credentials.User)
-	currentState.AddCustomData("agent_name", k.assistant.Name)
+	currentState.AddCustomData("agent_name", k.assistantName)
 
-	// create response message
-	response, err := k.generateTweetResponse(currentState, tweet)
+	// wallets people ask about are looked up so the reply can talk about them with real data
+	if k.sora != nil {
+		if walletLookups := k.sora.WalletLookups(tweet.TweetText); walletLookups != "" {
+			currentState.AddCustomData("wallet_lookups", walletLookups)
+		}
+	}
+	if status := k.holderStatus(tweet); status != nil {
+		currentState.AddCustomData("holder_status", k.sora.DescribeHolderStatus(status))
+	}
+
+	// create response message, regenerating it if the output guardrails block it
+	response, err := k.generateModeratedResponse(currentState, func() (*db.Fragment, error) {
+		return k.generateTweetResponse(currentState, tweet)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to generate tweet response: %w", err)
 	}
 
-	if err := k.assistant.PostProcess(response, currentState); err != nil {
-		return fmt.Errorf("failed to post process message: %w", err)
-	}
+	// with an approval queue the reply is only posted, and tipped, once approved
+	return k.publish(currentState, response, tweet, func(response *db.Fragment) error {
+		if err := k.assistant.PostProcess(response, currentState); err != nil {
+			return fmt.Errorf("failed to post process message: %w", err)
+		}
 
-	return nil
+		k.maybeTip(currentState, tweet)
+
+		return nil
+	})
 }
 
 // generateTweetResponse creates a response to a tweet by:
@@ -260,8 +316,18 @@ func (k *Twitter) handleTweetProcessing(tweet *twitter.ParsedTweet) error {
 // Returns the response fragment and any error encountered.
 func (k *Twitter) generateTweetResponse(currentState *state.State, tweet *twitter.ParsedTweet) (*db.Fragment, error) {
 	templateBuilder := state.NewPromptBuilder(currentState).
-		AddSystemSection(`{{.base_personality}}`).
-		AddSystemSection(`{{.sora_information}} {{.sora_token_data}}`).
+		AddSystemSection(`{{.base_personality}}`)
+
+	// market data is only in the prompt when the agent runs the sora manager
+	if k.runsManager(sora_manager.SoraManagerID) {
+		templateBuilder.
+			AddSystemSection(`{{.sora_information}} {{.watchlist_token_data}} {{.sora_onchain_data}}`).
+			WithManagerData(sora_manager.SoraInformation).
+			WithManagerData(sora_manager.WatchlistTokenData).
+			WithManagerData(sora_manager.SoraOnchainData)
+	}
+
+	templateBuilder.
 		AddSystemSection(`Your thinking process mirrors human stream-of-consciousness reasoning, while staying true to your core identity above. Your responses emerge from thorough self-questioning exploration that always maintains your unique personality traits and characteristics.
 
 CORE PRINCIPLES:
@@ -311,13 +377,22 @@ Available Context:
 
 # User Insights
 {{.actor_insights}}
-
+{{if .holder_status}}
+# User's Sora Holdings
+{{.holder_status}}
+Holders can get a bit more warmth from you and whales a bit more attention, but never promise them anything.
+{{end}}
 # Unique Insights
 {{.unique_insights}}
 
 Twitter Conversation:
 {{.twitter_conversations}}
-
+{{if .wallet_lookups}}
+# Wallet Lookups
+The user asked about these wallets, this is their on-chain data:
+{{.wallet_lookups}}
+Roast or praise the wallet based on these numbers. Only refer to a wallet by its shortened address and never name other tokens or paste any contract address.
+{{end}}
 Your response must follow this structure:
 
 <contemplator>
@@ -336,18 +411,28 @@ Your response must follow this structure:
 Task:
 Respond to the user's tweet marked with →`).
 		WithManagerData(personality.BasePersonality).
-		WithManagerData(insight.SessionInsights).
-		WithManagerData(insight.ActorInsights).
-		WithManagerData(insight.UniqueInsights).
-		WithManagerData(twitter_manager.TwitterConversations).
-		WithManagerData(sora_manager.SoraInformation).
-		WithManagerData(sora_manager.SoraTokenData)
+		WithManagerData(twitter_manager.TwitterConversations)
+
+	// without the insight manager the insight sections stay empty, like they are before any insight is made
+	if k.runsManager(manager.InsightManagerID) {
+		templateBuilder.
+			WithManagerData(insight.SessionInsights).
+			WithManagerData(insight.ActorInsights).
+			WithManagerData(insight.UniqueInsights)
+	}
+
+	// Suspected prompt injection gets a hardened prompt
+	injectionRisk := k.injectionRisk(currentState)
+	if injectionRisk.AtLeast(guardrails.InjectionRiskMedium) {
+		templateBuilder.AddSystemSection(hardenedReplyInstructions)
+	}
 
 	// Generate messages from template
 	messages, err := templateBuilder.Compose()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build template: %w", err)
 	}
+	messages = appendModerationFeedback(currentState, messages)
 
 	k.logger.WithFields(map[string]interface{}{
 		"messages": messages,
@@ -359,40 +444,64 @@ Respond to the user's tweet marked with →`).
 	// if err != nil {
 	// 	return nil, fmt.Errorf("failed to generate response: %w", err)
 	// }
-	// Generate completion
-	response, err := k.llmClient.GenerateCompletion(llm.CompletionRequest{
-		Messages:    messages,
-		ModelType:   llm.ModelTypeDefault,
-		Temperature: 0.7,
-		Tools:       k.solanaToolkit.GetTools(),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate completion: %v", err)
+	// Generate completion, running any requested tools until we get a final answer
+	// high risk replies don't get tools so injected instructions can't trigger them
+	var availableTools []toolkit.Tool
+	if !injectionRisk.AtLeast(guardrails.InjectionRiskHigh) {
+		availableTools = k.solanaToolkit.GetTools()
 	}
+	tools := newToolRun(k.ctx, availableTools, maxToolIterations)
 
-	// Extract the final answer from the response
+	var response llm.Message
 	finalAnswer := ""
-	if start := strings.Index(response.Content, "<final_answer>"); start != -1 {
-		content := response.Content[start+len("<final_answer>"):]
-		if end := strings.Index(content, "</final_answer>"); end != -1 {
-			finalAnswer = strings.TrimSpace(content[:end])
-		} else {
-			// If no closing tag, take the rest of the content
-			finalAnswer = strings.TrimSpace(content)
+	for iteration := 0; iteration < maxToolIterations && finalAnswer == ""; iteration++ {
+		request := llm.CompletionRequest{
+			Messages:    messages,
+			ModelType:   llm.ModelTypeDefault,
+			Temperature: 0.7,
+		}
+		// the last round and an exhausted budget get no tools, forcing an answer
+		if iteration < maxToolIterations-1 && !tools.exhausted() && len(tools.tools) > 0 {
+			request.Tools = tools.tools
+		}
+
+		response, err = tools.complete(k.languageModel, request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate completion: %v", err)
+		}
+
+		if response.ToolCall != nil {
+			k.logger.WithFields(map[string]interface{}{
+				"tool":      response.ToolCall.Name,
+				"arguments": response.ToolCall.Arguments,
+			}).Infof("Executing tool call")
+
+			messages = append(messages, tools.execute(response.ToolCall)...)
+			continue
+		}
+
+		finalAnswer = extractTagContent(response.Content, "final_answer")
+		if finalAnswer == "" {
+			messages = append(messages,
+				llm.NewAssistantMessage(response.Content),
+				llm.NewUserMessage("Finish your response using any tool results above. It must follow the required structure, including the <final_answer> section."),
+			)
 		}
 	}
 
 	if finalAnswer == "" {
-		return nil, fmt.Errorf("no final answer found in response")
+		return nil, fmt.Errorf("no final answer found in response after %d iterations", maxToolIterations)
 	}
 
 	k.logger.WithFields(map[string]interface{}{
 		"thought_process": response.Content,
 		"finalAnswer":     finalAnswer,
+		"tool_calls":      tools.history(),
 	}).Infof("Final answer")
+	currentState.AddCustomData(thoughtProcessKey, response.Content)
 
 	// Generate embedding for just the final answer
-	embedding, err := k.llmClient.EmbedText(finalAnswer)
+	embedding, err := k.languageModel.EmbedText(finalAnswer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create embedding for response: %v", err)
 	}
@@ -400,7 +509,7 @@ Respond to the user's tweet marked with →`).
 	// Create response fragment with just the final answer
 	responseFragment := &db.Fragment{
 		ID:        id.New(),
-		ActorID:   k.assistant.ID,
+		ActorID:   k.assistantID,
 		SessionID: id.FromString(tweet.TweetConversationID),
 		Content:   finalAnswer,
 		Embedding: pgvector.NewVector(embedding),
@@ -428,6 +537,12 @@ Respond to the user's tweet marked with →`).
 		return nil, fmt.Errorf("failed to decode tweet metadata: %w", err)
 	}
 
+	// Record which tools ran to produce this response
+	if invocations := tools.history(); len(invocations) > 0 {
+		metadata["tool_calls"] = invocations
+	}
+	recordPersonaVersion(currentState, metadata)
+
 	// Create response fragment
 	responseFragment.Metadata = metadata
 
@@ -435,28 +550,21 @@ Respond to the user's tweet marked with →`).
 }
 
 // checkGuardrails checks the state using the guardrails processor by calling ProcessWithParams
-func (k *Twitter) checkGuardrails(currentState *state.State) error {
-	if err := k.assistant.NewProcessBuilder().
-		WithState(currentState).
-		WithManagerFilter([]manager.ManagerID{guardrails.GuardrailsManagerID}).
-		ShouldStore(false).
-		Execute(); err != nil {
-		return fmt.Errorf("guardrails check failed: %w", err)
+// and returns its verdict. Errors are only returned when the check itself fails.
+func (k *Twitter) checkGuardrails(currentState *state.State) (*guardrails.ContentModerationResult, error) {
+	if err := k.assistant.ProcessWith(currentState, []manager.ManagerID{guardrails.GuardrailsManagerID}, false); err != nil {
+		return nil, fmt.Errorf("guardrails check failed: %w", err)
 	}
 
 	result, exists := currentState.GetManagerData(guardrails.GuardrailsResultKey)
 	if !exists {
-		return fmt.Errorf("guardrails result not found")
+		return nil, fmt.Errorf("guardrails result not found")
 	}
 
 	var guardRailsResult guardrails.ContentModerationResult
 	if err := mapstructure.Decode(result, &guardRailsResult); err != nil {
-		return fmt.Errorf("failed to decode guardrails result: %w", err)
+		return nil, fmt.Errorf("failed to decode guardrails result: %w", err)
 	}
 
-	if !guardRailsResult.Allowed {
-		return fmt.Errorf("guardrails check failed: %v", guardRailsResult.Reasons)
-	}
-
-	return nil
+	return &guardRailsResult, nil
 }
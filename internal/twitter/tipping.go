@@ -0,0 +1,66 @@
+package twitter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/soralabs/hana/internal/managers/guardrails"
+	"github.com/soralabs/hana/internal/tipping"
+	"github.com/soralabs/zen/id"
+	"github.com/soralabs/zen/managers/insight"
+	"github.com/soralabs/zen/pkg/twitter"
+	"github.com/soralabs/zen/state"
+)
+
+// maybeTip offers a replied tweet to the tipper. Only authors with a wallet linked by signature qualify,
+// and tweets suspected of prompt injection never do.
+// Tipping errors are logged, they never fail the reply.
+func (k *Twitter) maybeTip(currentState *state.State, tweet *twitter.ParsedTweet) {
+	if k.tipper == nil {
+		return
+	}
+
+	status := k.holderStatus(tweet)
+	if status == nil || !status.Verified {
+		return
+	}
+	if k.injectionRisk(currentState).AtLeast(guardrails.InjectionRiskLow) {
+		return
+	}
+
+	var insights []string
+	for _, key := range []state.StateDataKey{insight.SessionInsights, insight.ActorInsights} {
+		if value, exists := currentState.GetManagerData(key); exists {
+			insights = append(insights, fmt.Sprintf("%v", value))
+		}
+	}
+
+	record, err := k.tipper.Consider(k.ctx, tipping.Candidate{
+		ActorID:  id.FromString(tweet.UserID),
+		Username: tweet.UserName,
+		TweetID:  tweet.TweetID,
+		Text:     tweet.TweetText,
+		Wallet:   status.Wallet,
+		Insights: strings.Join(insights, "\n"),
+	})
+	if errors.Is(err, tipping.ErrStopped) {
+		return
+	}
+	if err != nil {
+		k.logger.Warnf("failed to tip tweet %s: %v", tweet.TweetID, err)
+		return
+	}
+	if record == nil {
+		return
+	}
+
+	k.logger.WithFields(map[string]interface{}{
+		"tweet_id":  tweet.TweetID,
+		"user":      tweet.UserName,
+		"wallet":    record.Wallet,
+		"amount":    record.Amount,
+		"status":    record.Status,
+		"signature": record.Signature,
+	}).Infof("Tipped tweet")
+}
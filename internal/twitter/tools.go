@@ -0,0 +1,164 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	toolkit "github.com/soralabs/toolkit/go"
+	"github.com/soralabs/zen/llm"
+)
+
+// maxToolIterations bounds how many tool executions and completion rounds
+// a single reply may use before the model is forced to answer.
+// Every completion round is a single request to the model, tool calls included.
+const maxToolIterations = 5
+
+// ToolInvocation records a single tool execution performed while generating a response
+type ToolInvocation struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Error     string `json:"error,omitempty"`
+}
+
+// errToolCallRequested stops the LLM provider when the model calls a tool.
+// zen's providers execute a tool call themselves and call the model again with the result,
+// a loop nothing bounds. Stopping it hands the call back so that every tool execution
+// is a completion round of our own, counted against the limit.
+var errToolCallRequested = errors.New("tool call requested")
+
+// toolRun tracks tool executions for a single response generation.
+// The provider only gets wrapped tools, which hand the call back instead of executing it.
+type toolRun struct {
+	ctx   context.Context
+	limit int
+
+	mu          sync.Mutex
+	invocations []ToolInvocation
+	// requested is the tool call that stopped the provider, until complete takes it
+	requested *llm.ToolCall
+
+	tools  []toolkit.Tool
+	lookup map[string]*trackedTool
+}
+
+// trackedTool wraps a toolkit tool for the provider, it describes the tool but doesn't run it
+type trackedTool struct {
+	toolkit.Tool
+	run *toolRun
+}
+
+// newToolRun creates a tool run wrapping the given tools with the given execution limit
+func newToolRun(ctx context.Context, tools []toolkit.Tool, limit int) *toolRun {
+	run := &toolRun{
+		ctx:    ctx,
+		limit:  limit,
+		lookup: make(map[string]*trackedTool, len(tools)),
+	}
+
+	for _, tool := range tools {
+		wrapped := &trackedTool{Tool: tool, run: run}
+		run.tools = append(run.tools, wrapped)
+		run.lookup[tool.GetName()] = wrapped
+	}
+
+	return run
+}
+
+// Execute records the call the model made and stops the provider, the run executes the call
+func (t *trackedTool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	t.run.mu.Lock()
+	defer t.run.mu.Unlock()
+
+	t.run.requested = &llm.ToolCall{
+		Name:      t.Tool.GetName(),
+		Arguments: string(params),
+	}
+	return nil, errToolCallRequested
+}
+
+// complete generates a completion. When the model calls a tool the call is returned
+// in the message for the caller to execute, whether or not the provider runs tools itself.
+func (r *toolRun) complete(languageModel LanguageModel, request llm.CompletionRequest) (llm.Message, error) {
+	response, err := languageModel.GenerateCompletion(request)
+	if !errors.Is(err, errToolCallRequested) {
+		return response, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	call := r.requested
+	r.requested = nil
+	return llm.Message{
+		Role:     llm.RoleAssistant,
+		ToolCall: call,
+	}, nil
+}
+
+// invoke executes a tool, records the invocation and enforces the execution limit.
+// A nil tool is recorded as an unknown tool call.
+func (r *toolRun) invoke(ctx context.Context, name string, params json.RawMessage, tool toolkit.Tool) json.RawMessage {
+	invocation := ToolInvocation{
+		Name:      name,
+		Arguments: string(params),
+	}
+
+	var result json.RawMessage
+	switch {
+	case tool == nil:
+		invocation.Error = "tool not found"
+	case r.exhausted():
+		invocation.Error = "tool limit reached, answer with the information you have"
+	default:
+		output, err := tool.Execute(ctx, params)
+		if err != nil {
+			invocation.Error = err.Error()
+		}
+		result = output
+	}
+
+	r.mu.Lock()
+	r.invocations = append(r.invocations, invocation)
+	r.mu.Unlock()
+
+	if invocation.Error != "" {
+		result, _ = json.Marshal(map[string]string{"error": invocation.Error})
+	}
+
+	return result
+}
+
+// execute runs a tool call handed back by the LLM and returns the messages
+// to append to the conversation: the assistant's call and the tool's result
+func (r *toolRun) execute(call *llm.ToolCall) []llm.Message {
+	var tool toolkit.Tool
+	if tracked, exists := r.lookup[call.Name]; exists {
+		tool = tracked.Tool
+	}
+
+	result := r.invoke(r.ctx, call.Name, json.RawMessage(call.Arguments), tool)
+
+	return []llm.Message{
+		{
+			Role:     llm.RoleAssistant,
+			ToolCall: call,
+		},
+		llm.NewToolMessage(string(result), call.Name),
+	}
+}
+
+// exhausted reports whether the run has used up its tool budget
+func (r *toolRun) exhausted() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.invocations) >= r.limit
+}
+
+// history returns a copy of all recorded tool invocations
+func (r *toolRun) history() []ToolInvocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ToolInvocation(nil), r.invocations...)
+}
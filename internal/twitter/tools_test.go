@@ -0,0 +1,130 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/soralabs/hana/internal/testutil"
+	"github.com/soralabs/hana/internal/twitter/twittertest"
+	toolkit "github.com/soralabs/toolkit/go"
+	"github.com/soralabs/zen/llm"
+	"github.com/soralabs/zen/pkg/twitter"
+)
+
+// countingTool is a tool that counts its executions
+type countingTool struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (t *countingTool) GetName() string        { return "get_price" }
+func (t *countingTool) GetDescription() string { return "Gets the price of a token" }
+func (t *countingTool) GetSchema() toolkit.Schema {
+	return toolkit.Schema{Parameters: json.RawMessage(`{"type":"object","properties":{}}`)}
+}
+
+func (t *countingTool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls++
+	return json.RawMessage(`{"price":1}`), nil
+}
+
+func (t *countingTool) executions() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.calls
+}
+
+// openAIModel completes with zen's OpenAI provider and embeds like the scripted model
+type openAIModel struct {
+	*llm.LLMClient
+	embedder *twittertest.ScriptedLLM
+}
+
+func (m openAIModel) EmbedText(text string) ([]float32, error) {
+	return m.embedder.EmbedText(text)
+}
+
+// TestReplyBoundsToolCallsOfTheProvider runs a reply through zen's OpenAI provider against a model
+// that calls a tool whenever it is offered one. The provider would execute the calls and ask the
+// model again until it stopped, the reply must stay within its completion rounds instead.
+func TestReplyBoundsToolCallsOfTheProvider(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []map[string]json.RawMessage
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		requests = append(requests, request)
+		mu.Unlock()
+
+		message := map[string]interface{}{
+			"role":    "assistant",
+			"content": "<contemplator>the chain is quiet</contemplator>\n<final_answer>gm, nothing moved</final_answer>",
+		}
+		if _, offered := request["functions"]; offered {
+			message = map[string]interface{}{
+				"role":          "assistant",
+				"function_call": map[string]string{"name": "get_price", "arguments": "{}"},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"index": 0, "message": message}},
+		})
+	}))
+	defer server.Close()
+
+	log := testutil.NewLogger(t)
+	client := testutil.NewLLMClient(t, log, server.URL)
+
+	timeline := twittertest.NewTimeline(
+		twittertest.User{Username: testAgent},
+		twittertest.User{Username: "alice"},
+	)
+	k, _ := newTestTwitter(t, timeline, openAIModel{LLMClient: client, embedder: twittertest.NewScriptedLLM()})
+	tool := &countingTool{}
+	k.solanaToolkit = toolkit.NewToolkit(toolkit.WithTools(tool))
+
+	mention := timeline.AddTweet(twitter.ParsedTweet{
+		UserName:            "alice",
+		TweetText:           "what's the price hana?",
+		InReplyToScreenName: testAgent,
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- k.processAllTweets([]*twitter.ParsedTweet{mention})
+	}()
+	waitForPosts(t, timeline, 1)
+	close(k.stopChan)
+	if err := <-done; err != nil {
+		t.Fatalf("processAllTweets: %v", err)
+	}
+
+	if posts := timeline.Posts(); posts[0].TweetText != "gm, nothing moved" {
+		t.Errorf("got reply %q, want the final answer", posts[0].TweetText)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != maxToolIterations {
+		t.Errorf("got %d completion requests, want %d", len(requests), maxToolIterations)
+	}
+	if _, offered := requests[len(requests)-1]["functions"]; offered {
+		t.Errorf("the last completion round was offered tools")
+	}
+	if executions := tool.executions(); executions != maxToolIterations-1 {
+		t.Errorf("got %d tool executions, want %d", executions, maxToolIterations-1)
+	}
+}
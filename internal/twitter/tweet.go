@@ -8,6 +8,7 @@ import (
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/pgvector/pgvector-go"
+	"github.com/soralabs/hana/internal/managers/guardrails"
 	sora_manager "github.com/soralabs/hana/internal/managers/sora"
 	"github.com/soralabs/zen/db"
 	"github.com/soralabs/zen/id"
@@ -22,7 +23,7 @@ func (k *Twitter) tweetInterval() {
 	k.logger.Info("Starting tweet interval")
 
 	// static session
-	if err := k.assistant.UpsertSession(id.FromString(k.assistant.Name)); err != nil {
+	if err := k.assistant.UpsertSession(id.FromString(k.assistantName)); err != nil {
 		k.logger.Errorf("failed to upsert conversation: %v", err)
 	}
 
@@ -35,7 +36,7 @@ func (k *Twitter) tweetInterval() {
 			k.logger.Infof("Tweeting stopped")
 			return
 		default:
-			if err := k.tweet(); err != nil {
+			if err := k.tweet(nil); err != nil {
 				k.logger.Errorf("Failed to tweet: %v", err)
 			}
 
@@ -55,9 +56,37 @@ func (k *Twitter) tweetInterval() {
 	}
 }
 
-func (k *Twitter) tweet() error {
+// alertTweets tweets about the market events fired by the sora manager as they come in,
+// independently of the tweet interval
+func (k *Twitter) alertTweets() {
+	k.logger.Info("Starting market alert tweets")
+
+	for {
+		select {
+		case <-k.ctx.Done():
+			k.logger.Infof("Market alert tweets stopped")
+			return
+		case <-k.stopChan:
+			k.logger.Infof("Market alert tweets stopped")
+			return
+		case event := <-k.sora.Alerts():
+			k.logger.WithFields(map[string]interface{}{
+				"token": event.Token.Name,
+				"type":  event.Type,
+			}).Infof("Tweeting about market event")
+
+			if err := k.tweet(&event); err != nil {
+				k.logger.Errorf("Failed to tweet about market event: %v", err)
+			}
+		}
+	}
+}
+
+// tweet generates and posts a standalone tweet.
+// A market event is injected into the state and the tweet is written about it.
+func (k *Twitter) tweet(event *sora_manager.MarketEvent) error {
 	// static session
-	sessionId := id.FromString(k.assistant.Name)
+	sessionId := id.FromString(k.assistantName)
 
 	// Create a zero vector with 1536 dimensions (standard embedding size)
 	zeroEmbedding := make([]float32, 1536)
@@ -66,7 +95,7 @@ func (k *Twitter) tweet() error {
 	// empty tweet fragment content because we aren't replying to anything
 	tweetFragment := &db.Fragment{
 		ID:        id.New(),
-		ActorID:   k.assistant.ID,
+		ActorID:   k.assistantID,
 		SessionID: sessionId,
 		Content:   "",
 		Embedding: embeddingVector, // Use the proper-sized zero embedding
@@ -120,6 +149,9 @@ func (k *Twitter) tweet() error {
 	}
 
 	currentState.AddCustomData("tweet_count", twitterDetails.Data.User.Result.Legacy.StatusesCount)
+	if event != nil {
+		currentState.AddCustomData("market_event", event.Description)
+	}
 
 	// Add recent interactions to state
 	if len(recentTweets) > 0 {
@@ -135,29 +167,26 @@ func (k *Twitter) tweet() error {
 		currentState.AddCustomData("recent_interactions", strings.Join(recentInteractions, "\n"))
 	}
 
-	if err := k.assistant.NewProcessBuilder().
-		WithState(currentState).
-		WithManagerFilter([]manager.ManagerID{manager.PersonalityManagerID, sora_manager.SoraManagerID}).
-		ShouldStore(false).
-		Execute(); err != nil {
+	if err := k.assistant.ProcessWith(currentState, []manager.ManagerID{manager.PersonalityManagerID, sora_manager.SoraManagerID}, false); err != nil {
 		return fmt.Errorf("failed to process message: %w", err)
 	}
 
-	// create response message
-	response, err := k.generateTweet(currentState)
+	// create response message, regenerating it if the output guardrails block it
+	response, err := k.generateModeratedResponse(currentState, func() (*db.Fragment, error) {
+		return k.generateTweet(currentState)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to generate tweet response: %w", err)
 	}
 
-	if err := k.assistant.NewPostProcessBuilder().
-		WithState(currentState).
-		WithResponse(response).
-		WithManagerFilter([]manager.ManagerID{manager.TwitterManagerID, manager.PersonalityManagerID}).
-		Execute(); err != nil {
-		return fmt.Errorf("failed to post process message: %w", err)
-	}
+	return k.publish(currentState, response, nil, func(response *db.Fragment) error {
+		managers := []manager.ManagerID{guardrails.GuardrailsManagerID, manager.TwitterManagerID, manager.PersonalityManagerID}
+		if err := k.assistant.PostProcessWith(response, currentState, managers, true); err != nil {
+			return fmt.Errorf("failed to post process message: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (k *Twitter) generateTweet(currentState *state.State) (*db.Fragment, error) {
@@ -175,9 +204,24 @@ func (k *Twitter) generateTweet(currentState *state.State) (*db.Fragment, error)
 
 	templateBuilder.
 		AddSystemSection(`
-{{.base_personality}}`).
-		AddUserSection(`{{.sora_information}}
-{{.sora_token_data}}`, "").
+{{.base_personality}}`)
+
+	// market data is only in the prompt when the agent runs the sora manager
+	if k.runsManager(sora_manager.SoraManagerID) {
+		templateBuilder.
+			AddUserSection(`{{.sora_information}}
+{{.watchlist_token_data}}
+{{.watchlist_token_trends}}
+{{.sora_onchain_data}}
+{{.sora_whale_activity}}`, "").
+			WithManagerData(sora_manager.SoraInformation).
+			WithManagerData(sora_manager.WatchlistTokenData).
+			WithManagerData(sora_manager.WatchlistTokenTrends).
+			WithManagerData(sora_manager.SoraOnchainData).
+			WithManagerData(sora_manager.SoraWhaleActivity)
+	}
+
+	templateBuilder.
 		AddUserSection(`Your thinking process mirrors human stream-of-consciousness reasoning, while staying true to your core identity above. Your responses emerge from thorough self-questioning exploration that always maintains your unique personality traits and characteristics.
 
 CORE PRINCIPLES:
@@ -213,8 +257,16 @@ TWEET GUIDELINES:
 7. Do not use hashtags
 8. Tweets do not have to build on previous tweets, they can be standalone
 9. Do not roleplay or add actions to your tweets
-10. SOMETIMES speak about Sora token statistics
-
+10. SOMETIMES speak about Sora token statistics, the price history tells you how the token has moved over the last days and weeks
+    and the whale activity tells you who has been buying and selling big
+11. You can comment on the ecosystem tokens in the market data, but never tell people to buy them
+
+{{if .market_event}}
+MARKET EVENT:
+Something just happened with the token, this tweet must be about it:
+{{.market_event}}
+React to it in your own voice. Share the numbers, but never tell people to buy or sell.
+{{end}}
 This is your {{.tweet_count}}th tweet (including replies). If this is a significant milestone, come up with a unique way to celebrate it.
 
 Available Context:
@@ -238,15 +290,14 @@ Your response must follow this structure:
 <tweet>
 [Flow of the thought process. Do not make it so summary-like, but rather flow-like.]
 </tweet>`, "").
-		WithManagerData(personality.BasePersonality).
-		WithManagerData(sora_manager.SoraInformation).
-		WithManagerData(sora_manager.SoraTokenData)
+		WithManagerData(personality.BasePersonality)
 
 	// Generate messages from template
 	messages, err := templateBuilder.Compose()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build template: %w", err)
 	}
+	messages = appendModerationFeedback(currentState, messages)
 
 	k.logger.WithFields(map[string]interface{}{
 		"messages": messages,
@@ -259,7 +310,7 @@ Your response must follow this structure:
 	// 	return nil, fmt.Errorf("failed to generate response: %w", err)
 	// }
 	// Generate completion
-	response, err := k.llmClient.GenerateCompletion(llm.CompletionRequest{
+	response, err := k.languageModel.GenerateCompletion(llm.CompletionRequest{
 		Messages:    messages,
 		ModelType:   llm.ModelTypeAdvanced,
 		Temperature: 0.0,
@@ -288,9 +339,10 @@ Your response must follow this structure:
 		"thought_process": response.Content,
 		"finalAnswer":     finalAnswer,
 	}).Infof("Final answer")
+	currentState.AddCustomData(thoughtProcessKey, response.Content)
 
 	// Generate embedding for just the final answer
-	embedding, err := k.llmClient.EmbedText(finalAnswer)
+	embedding, err := k.languageModel.EmbedText(finalAnswer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create embedding for response: %v", err)
 	}
@@ -298,7 +350,7 @@ Your response must follow this structure:
 	// Create response fragment with just the final answer
 	responseFragment := &db.Fragment{
 		ID:        id.New(),
-		ActorID:   k.assistant.ID,
+		ActorID:   k.assistantID,
 		SessionID: currentState.Input.SessionID,
 		Content:   finalAnswer,
 		Embedding: pgvector.NewVector(embedding),
@@ -325,6 +377,8 @@ Your response must follow this structure:
 		return nil, fmt.Errorf("failed to decode tweet metadata: %w", err)
 	}
 
+	recordPersonaVersion(currentState, metadata)
+
 	// Create response fragment
 	responseFragment.Metadata = metadata
 
@@ -0,0 +1,104 @@
+package twittertest
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/soralabs/zen/llm"
+)
+
+// embeddingDimensions matches the size of the embeddings zen stores
+const embeddingDimensions = 1536
+
+// ResponseFunc answers a completion request
+type ResponseFunc func(req llm.CompletionRequest) (string, error)
+
+// ScriptedLLM answers completions from a script, in order, and embeds text deterministically.
+// Every completion request is recorded so tests can inspect the prompts.
+type ScriptedLLM struct {
+	mu        sync.Mutex
+	responses []ResponseFunc
+	requests  []llm.CompletionRequest
+}
+
+// NewScriptedLLM creates a language model that answers with the responses, in order
+func NewScriptedLLM(responses ...string) *ScriptedLLM {
+	l := &ScriptedLLM{}
+	l.Reply(responses...)
+	return l
+}
+
+// Reply queues fixed responses
+func (l *ScriptedLLM) Reply(responses ...string) {
+	for _, response := range responses {
+		response := response
+		l.ReplyWith(func(llm.CompletionRequest) (string, error) {
+			return response, nil
+		})
+	}
+}
+
+// ReplyWith queues a response computed from the request
+func (l *ScriptedLLM) ReplyWith(response ResponseFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.responses = append(l.responses, response)
+}
+
+// Requests returns the completion requests received so far
+func (l *ScriptedLLM) Requests() []llm.CompletionRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]llm.CompletionRequest(nil), l.requests...)
+}
+
+// Remaining returns how many scripted responses haven't been used yet
+func (l *ScriptedLLM) Remaining() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.responses)
+}
+
+// GenerateCompletion answers with the next scripted response, failing once the script runs out
+func (l *ScriptedLLM) GenerateCompletion(req llm.CompletionRequest) (llm.Message, error) {
+	l.mu.Lock()
+	l.requests = append(l.requests, req)
+	if len(l.responses) == 0 {
+		l.mu.Unlock()
+		return llm.Message{}, fmt.Errorf("no scripted response left for completion request %d", len(l.requests))
+	}
+	response := l.responses[0]
+	l.responses = l.responses[1:]
+	l.mu.Unlock()
+
+	content, err := response(req)
+	if err != nil {
+		return llm.Message{}, err
+	}
+
+	return llm.Message{
+		Role:    llm.RoleAssistant,
+		Content: content,
+	}, nil
+}
+
+// EmbedText returns a vector derived from a hash of the text, the same text always gets the same vector
+func (l *ScriptedLLM) EmbedText(text string) ([]float32, error) {
+	hash := fnv.New64a()
+	hash.Write([]byte(text))
+	seed := hash.Sum64()
+
+	embedding := make([]float32, embeddingDimensions)
+	for i := range embedding {
+		// xorshift keeps the values spread without pulling in math/rand
+		seed ^= seed << 13
+		seed ^= seed >> 7
+		seed ^= seed << 17
+		embedding[i] = float32(seed%2000)/1000 - 1
+	}
+	return embedding, nil
+}
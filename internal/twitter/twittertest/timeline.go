@@ -0,0 +1,269 @@
+// Package twittertest provides in-memory fakes of the Twitter client and the language model,
+// so the agent can run end to end without network access.
+package twittertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soralabs/zen/pkg/twitter"
+)
+
+// twitterTimeLayout is how the Twitter API formats created_at
+const twitterTimeLayout = "Mon Jan 2 15:04:05 -0700 2006"
+
+// User is an account on the fake timeline
+type User struct {
+	ID            string
+	Username      string
+	DisplayName   string
+	Bio           string
+	StatusesCount int
+}
+
+// Timeline is an in-memory Twitter that serves searches in the same GraphQL format as the real API.
+// Tweets created through it are posted by the agent, added to the timeline and recorded as posts.
+type Timeline struct {
+	mu     sync.Mutex
+	agent  string
+	users  map[string]*User
+	tweets []*twitter.ParsedTweet
+	posts  []*twitter.ParsedTweet
+	nextID int
+}
+
+// NewTimeline creates an empty timeline where the agent and the other users have accounts
+func NewTimeline(agent User, users ...User) *Timeline {
+	t := &Timeline{
+		agent:  agent.Username,
+		users:  make(map[string]*User),
+		nextID: 1000,
+	}
+	t.AddUser(agent)
+	for _, user := range users {
+		t.AddUser(user)
+	}
+	return t
+}
+
+// AddUser adds an account, filling in its ID and display name when they are empty
+func (t *Timeline) AddUser(user User) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if user.ID == "" {
+		user.ID = t.newID()
+	}
+	if user.DisplayName == "" {
+		user.DisplayName = user.Username
+	}
+	t.users[strings.ToLower(user.Username)] = &user
+}
+
+// AddTweet adds a tweet by an existing user and returns it with its IDs and creation time filled in.
+// A tweet without a conversation starts its own, or joins the one of the tweet it replies to.
+func (t *Timeline) AddTweet(tweet twitter.ParsedTweet) *twitter.ParsedTweet {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.addTweet(tweet)
+}
+
+// Posts returns the tweets the agent created through CreateTweet, oldest first
+func (t *Timeline) Posts() []*twitter.ParsedTweet {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]*twitter.ParsedTweet(nil), t.posts...)
+}
+
+// SearchReplies returns the newest tweets addressed to the user, like the "to:username" search
+func (t *Timeline) SearchReplies(username string, limit int) (*twitter.SearchTimelineResponse, error) {
+	t.mu.Lock()
+	var replies []*twitter.ParsedTweet
+	for _, tweet := range t.tweets {
+		if strings.EqualFold(tweet.InReplyToScreenName, username) {
+			replies = append(replies, tweet)
+		}
+	}
+	t.mu.Unlock()
+
+	sort.SliceStable(replies, func(i, j int) bool {
+		return replies[i].TweetCreatedAt > replies[j].TweetCreatedAt
+	})
+	if len(replies) > limit {
+		replies = replies[:limit]
+	}
+
+	return searchTimelineResponse(replies)
+}
+
+// ParseSearchTimelineResponse parses a search response with the zen client's own parser
+func (t *Timeline) ParseSearchTimelineResponse(res *twitter.SearchTimelineResponse) ([]*twitter.ParsedTweet, error) {
+	return (&twitter.Client{}).ParseSearchTimelineResponse(res)
+}
+
+// GetUserDetails returns the profile of a user, failing for unknown users like the real API
+func (t *Timeline) GetUserDetails(username string) (*twitter.GetUserDetailsResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	user, exists := t.users[strings.ToLower(username)]
+	if !exists {
+		return nil, fmt.Errorf("user %s not found", username)
+	}
+
+	var res twitter.GetUserDetailsResponse
+	res.Data.User.Result.RestID = user.ID
+	res.Data.User.Result.Legacy.ScreenName = user.Username
+	res.Data.User.Result.Legacy.Name = user.DisplayName
+	res.Data.User.Result.Legacy.Description = user.Bio
+	res.Data.User.Result.Legacy.StatusesCount = user.StatusesCount
+	return &res, nil
+}
+
+// CreateTweet posts a tweet as the agent, as a reply when the options name a tweet, and adds it to the timeline
+func (t *Timeline) CreateTweet(tweetContent string, opts *twitter.TweetOptions) (*twitter.CreateTweetResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	agent, exists := t.users[strings.ToLower(t.agent)]
+	if !exists {
+		return nil, fmt.Errorf("user %s not found", t.agent)
+	}
+
+	tweet := twitter.ParsedTweet{
+		UserName:  agent.Username,
+		TweetText: tweetContent,
+	}
+	if opts != nil && opts.ReplyToTweetID != "" {
+		parent := t.findTweet(opts.ReplyToTweetID)
+		if parent == nil {
+			return nil, fmt.Errorf("tweet %s not found", opts.ReplyToTweetID)
+		}
+		tweet.InReplyToTweetID = parent.TweetID
+		tweet.InReplyToScreenName = parent.UserName
+	}
+
+	posted := t.addTweet(tweet)
+	agent.StatusesCount++
+	t.posts = append(t.posts, posted)
+
+	var res twitter.CreateTweetResponse
+	res.Data.CreateTweet.TweetResults.Result.RestID = posted.TweetID
+	return &res, nil
+}
+
+// addTweet fills in the tweet and adds it to the timeline, the lock must be held
+func (t *Timeline) addTweet(tweet twitter.ParsedTweet) *twitter.ParsedTweet {
+	if user, exists := t.users[strings.ToLower(tweet.UserName)]; exists {
+		tweet.UserID = user.ID
+		tweet.UserName = user.Username
+		tweet.DisplayName = user.DisplayName
+	}
+	if tweet.TweetID == "" {
+		tweet.TweetID = t.newID()
+	}
+	if tweet.TweetCreatedAt == 0 {
+		tweet.TweetCreatedAt = time.Now().Unix()
+	}
+	if tweet.TweetConversationID == "" {
+		tweet.TweetConversationID = tweet.TweetID
+		if parent := t.findTweet(tweet.InReplyToTweetID); parent != nil {
+			tweet.TweetConversationID = parent.TweetConversationID
+		}
+	}
+
+	t.tweets = append(t.tweets, &tweet)
+	return &tweet
+}
+
+// findTweet returns the tweet with the ID, nil if there is none, the lock must be held
+func (t *Timeline) findTweet(tweetID string) *twitter.ParsedTweet {
+	if tweetID == "" {
+		return nil
+	}
+	for _, tweet := range t.tweets {
+		if tweet.TweetID == tweetID {
+			return tweet
+		}
+	}
+	return nil
+}
+
+// newID returns a new tweet or user ID, the lock must be held
+func (t *Timeline) newID() string {
+	t.nextID++
+	return strconv.Itoa(t.nextID)
+}
+
+// searchTimelineResponse encodes tweets the way the SearchTimeline GraphQL endpoint returns them
+func searchTimelineResponse(tweets []*twitter.ParsedTweet) (*twitter.SearchTimelineResponse, error) {
+	entries := make([]map[string]interface{}, 0, len(tweets))
+	for _, tweet := range tweets {
+		entries = append(entries, map[string]interface{}{
+			"entryId": "tweet-" + tweet.TweetID,
+			"content": map[string]interface{}{
+				"entryType": "TimelineTimelineItem",
+				"itemContent": map[string]interface{}{
+					"tweet_results": map[string]interface{}{
+						"result": map[string]interface{}{
+							"rest_id": tweet.TweetID,
+							"core": map[string]interface{}{
+								"user_results": map[string]interface{}{
+									"result": map[string]interface{}{
+										"rest_id": tweet.UserID,
+										"legacy": map[string]interface{}{
+											"name":        tweet.DisplayName,
+											"screen_name": tweet.UserName,
+										},
+									},
+								},
+							},
+							"legacy": map[string]interface{}{
+								"id_str":                    tweet.TweetID,
+								"user_id_str":               tweet.UserID,
+								"conversation_id_str":       tweet.TweetConversationID,
+								"created_at":                time.Unix(tweet.TweetCreatedAt, 0).UTC().Format(twitterTimeLayout),
+								"full_text":                 tweet.TweetText,
+								"in_reply_to_status_id_str": tweet.InReplyToTweetID,
+								"in_reply_to_screen_name":   tweet.InReplyToScreenName,
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{
+			"search_by_raw_query": map[string]interface{}{
+				"search_timeline": map[string]interface{}{
+					"timeline": map[string]interface{}{
+						"instructions": []map[string]interface{}{
+							{
+								"type":    "TimelineAddEntries",
+								"entries": entries,
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode search response: %w", err)
+	}
+
+	var res twitter.SearchTimelineResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("failed to decode search response: %w", err)
+	}
+	return &res, nil
+}
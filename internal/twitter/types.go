@@ -2,33 +2,79 @@ package twitter
 
 import (
 	"context"
+	"sync"
 	"time"
 
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/soralabs/hana/internal/approval"
+	"github.com/soralabs/hana/internal/managers/guardrails"
+	sora_manager "github.com/soralabs/hana/internal/managers/sora"
+	"github.com/soralabs/hana/internal/marketdata"
+	"github.com/soralabs/hana/internal/tipping"
 	toolkit "github.com/soralabs/toolkit/go"
-	"github.com/soralabs/zen/engine"
+	"github.com/soralabs/zen/id"
 	"github.com/soralabs/zen/llm"
 	"github.com/soralabs/zen/logger"
+	"github.com/soralabs/zen/manager"
 	"github.com/soralabs/zen/options"
-	"github.com/soralabs/zen/pkg/twitter"
 	"gorm.io/gorm"
 )
 
 type Twitter struct {
 	options.RequiredFields
 
-	ctx       context.Context
-	logger    *logger.Logger
-	database  *gorm.DB
-	llmClient *llm.LLMClient
+	ctx      context.Context
+	logger   *logger.Logger
+	database *gorm.DB
+	// llmClient is handed to the zen engine and managers, the agent itself calls languageModel
+	llmClient     *llm.LLMClient
+	languageModel LanguageModel
 
-	assistant *engine.Engine
+	assistant     Assistant
+	assistantID   id.ID
+	assistantName string
+	guardrails    Guardrails
+	// sora is nil when the agent doesn't run the sora manager
+	sora    Sora
+	persona PersonaSource
+	// managers are the optional managers the agent runs, see OptionalManagers
+	managers []manager.ManagerID
+	// schema is the Postgres schema of the agent's sessions, actors and fragments, empty for the database's own
+	schema string
+	// ownsDatabase is set when database is the agent's own pool, which is closed when the agent stops
+	ownsDatabase bool
 
-	twitterClient *twitter.Client
+	twitterClient TwitterClient
 	twitterConfig TwitterConfig
 
 	solanaToolkit *toolkit.Toolkit
+	solanaRPC     *rpc.Client
+
+	guardrailsPolicy *guardrails.Policy
+	allowedCashtags  []string
+	tokenWatchlist   []sora_manager.WatchedToken
+	whaleConfig      sora_manager.WhaleConfig
+	// marketData is optional, agents sharing one share its rate limits and caches
+	marketData marketdata.MarketDataProvider
+
+	// personaPath is the persona file, it is read again when the persona is reloaded
+	personaPath string
+
+	holderTierWeights map[sora_manager.HolderTier]float64
+	// tipper is optional, tips are only sent when it is set
+	tipper *tipping.Tipper
+	// approvals is optional, when set responses wait for approval before they are posted
+	approvals *approval.Queue
+	// dryRunPath enables dry run mode, tweets are recorded to the shadow sink instead of being posted
+	dryRunPath string
+	shadow     *shadowSink
+	// bioCheckedAt is when the authors checked within bioCheckInterval had their bio checked for a wallet,
+	// only used by the monitor loop
+	bioCheckedAt map[string]time.Time
 
 	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
 }
 
 type TwitterCredentials struct {
@@ -46,4 +92,5 @@ type TwitterConfig struct {
 	MonitorInterval IntervalConfig
 	TweetInterval   IntervalConfig
 	Credentials     TwitterCredentials
+	ShutdownTimeout time.Duration
 }